@@ -0,0 +1,14 @@
+package constants
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus int
+
+var DeliveryStatuses = struct {
+	Pending,
+	Delivered,
+	Failed DeliveryStatus
+}{
+	Pending:   0,
+	Delivered: 1,
+	Failed:    2,
+}
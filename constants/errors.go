@@ -3,7 +3,34 @@ package constants
 import "errors"
 
 var ErrInvalidId = errors.New("invalid id")
+var ErrTooManyIds = errors.New("too many ids")
 var ErrMangaNotFound = errors.New("manga does not exists")
 var ErrChapterNotFound = errors.New("chapter does not exists")
 var ErrHistoryNotFound = errors.New("history does not exists")
 var ErrTagNotFound = errors.New("tag does not exists")
+var ErrAuthorNotFound = errors.New("author does not exists")
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrOffline = errors.New("offline")
+var ErrQueued = errors.New("offline, the task has been queued")
+var ErrNotUpdating = errors.New("library is not being updated")
+var ErrRunNotFound = errors.New("update run does not exists")
+var ErrNoFailedUpdates = errors.New("no failed updates to retry")
+var ErrWebhookNotFound = errors.New("webhook does not exists")
+var ErrInvalidWebhookURL = errors.New("webhook url must be http or https")
+var ErrFeedDisabled = errors.New("feed is disabled")
+var ErrInvalidFeedToken = errors.New("invalid feed token")
+var ErrChapterNotDownloaded = errors.New("chapter has not been downloaded")
+var ErrInvalidSubscription = errors.New("push subscription requires an endpoint and keys")
+var ErrFollowNotFound = errors.New("follow does not exists")
+var ErrInvalidFollowType = errors.New("only scanlation groups and authors can be followed")
+var ErrInvalidLanguage = errors.New("invalid language")
+var ErrEmailNotConfigured = errors.New("email requires an smtp host, a sender and recipients")
+var ErrNoNode = errors.New("no MangaDex@Home node is available")
+var ErrInvalidPage = errors.New("invalid page")
+var ErrRateLimited = errors.New("rate limited, too many requests")
+var ErrForbidden = errors.New("access denied")
+var ErrUpstreamUnavailable = errors.New("server is unavailable")
+var ErrInvalidProxy = errors.New("proxy must be an http, https or socks5 url")
+var ErrInvalidEndpoint = errors.New("endpoint must be an http or https url")
+var ErrShuttingDown = errors.New("server is shutting down")
+var ErrResultWindowExceeded = errors.New("too many chapters have been updated at the same time")
@@ -0,0 +1,37 @@
+package constants
+
+// Event is something which happened in the library, delivered to webhooks
+type Event int
+
+var Events = struct {
+	Ping,
+	ChapterAdded,
+	MangaFollowed,
+	MangaUnfollowed,
+	StatusChanged,
+	UpdateFinished Event
+}{
+	Ping:            0,
+	ChapterAdded:    1,
+	MangaFollowed:   2,
+	MangaUnfollowed: 3,
+	StatusChanged:   4,
+	UpdateFinished:  5,
+}
+
+func (self Event) String() string {
+	switch self {
+	case Events.ChapterAdded:
+		return "chapter.added"
+	case Events.MangaFollowed:
+		return "manga.followed"
+	case Events.MangaUnfollowed:
+		return "manga.unfollowed"
+	case Events.StatusChanged:
+		return "manga.status"
+	case Events.UpdateFinished:
+		return "update.finished"
+	default:
+		return "ping"
+	}
+}
@@ -0,0 +1,20 @@
+package constants
+
+// Failure is the reason a manga could not be updated
+type Failure int
+
+var Failures = struct {
+	None,
+	Other,
+	NotFound,
+	Network,
+	RateLimited,
+	Forbidden Failure
+}{
+	None:        0,
+	Other:       1,
+	NotFound:    2,
+	Network:     3,
+	RateLimited: 4,
+	Forbidden:   5,
+}
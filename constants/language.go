@@ -2,27 +2,224 @@ package constants
 
 type Language int
 
+// Languages are the translated languages of MangaDex,
+// the values are stored in the database so new languages must be appended.
 var Languages = struct {
 	English,
 	Japan,
 	Chinese,
-	Korean Language
+	Korean,
+	Arabic,
+	Azerbaijani,
+	Belarusian,
+	Bulgarian,
+	Bengali,
+	Catalan,
+	Czech,
+	Chuvash,
+	Danish,
+	German,
+	Greek,
+	Esperanto,
+	Spanish,
+	SpanishLatAm,
+	Estonian,
+	Basque,
+	Persian,
+	Finnish,
+	French,
+	Irish,
+	Hebrew,
+	Hindi,
+	Croatian,
+	Hungarian,
+	Indonesian,
+	Italian,
+	JapanRomanized,
+	Javanese,
+	Georgian,
+	Kazakh,
+	KoreanRomanized,
+	Latin,
+	Lithuanian,
+	Mongolian,
+	Malay,
+	Burmese,
+	Nepali,
+	Dutch,
+	Norwegian,
+	Polish,
+	Portuguese,
+	PortugueseBrazil,
+	Romanian,
+	Russian,
+	Slovak,
+	Albanian,
+	Serbian,
+	Swedish,
+	Tamil,
+	Telugu,
+	Thai,
+	Filipino,
+	Turkish,
+	Ukrainian,
+	Urdu,
+	Uzbek,
+	Vietnamese,
+	ChineseTraditional,
+	ChineseRomanized Language
 }{
-	English: 1,
-	Japan:   2,
-	Chinese: 3,
-	Korean:  4,
+	English:            1,
+	Japan:              2,
+	Chinese:            3,
+	Korean:             4,
+	Arabic:             5,
+	Azerbaijani:        6,
+	Belarusian:         7,
+	Bulgarian:          8,
+	Bengali:            9,
+	Catalan:            10,
+	Czech:              11,
+	Chuvash:            12,
+	Danish:             13,
+	German:             14,
+	Greek:              15,
+	Esperanto:          16,
+	Spanish:            17,
+	SpanishLatAm:       18,
+	Estonian:           19,
+	Basque:             20,
+	Persian:            21,
+	Finnish:            22,
+	French:             23,
+	Irish:              24,
+	Hebrew:             25,
+	Hindi:              26,
+	Croatian:           27,
+	Hungarian:          28,
+	Indonesian:         29,
+	Italian:            30,
+	JapanRomanized:     31,
+	Javanese:           32,
+	Georgian:           33,
+	Kazakh:             34,
+	KoreanRomanized:    35,
+	Latin:              36,
+	Lithuanian:         37,
+	Mongolian:          38,
+	Malay:              39,
+	Burmese:            40,
+	Nepali:             41,
+	Dutch:              42,
+	Norwegian:          43,
+	Polish:             44,
+	Portuguese:         45,
+	PortugueseBrazil:   46,
+	Romanian:           47,
+	Russian:            48,
+	Slovak:             49,
+	Albanian:           50,
+	Serbian:            51,
+	Swedish:            52,
+	Tamil:              53,
+	Telugu:             54,
+	Thai:               55,
+	Filipino:           56,
+	Turkish:            57,
+	Ukrainian:          58,
+	Urdu:               59,
+	Uzbek:              60,
+	Vietnamese:         61,
+	ChineseTraditional: 62,
+	ChineseRomanized:   63,
+}
+
+// languageCodes maps the languages to their MangaDex codes
+var languageCodes = map[Language]string{
+	Languages.English:            "en",
+	Languages.Japan:              "ja",
+	Languages.Chinese:            "zh",
+	Languages.Korean:             "ko",
+	Languages.Arabic:             "ar",
+	Languages.Azerbaijani:        "az",
+	Languages.Belarusian:         "be",
+	Languages.Bulgarian:          "bg",
+	Languages.Bengali:            "bn",
+	Languages.Catalan:            "ca",
+	Languages.Czech:              "cs",
+	Languages.Chuvash:            "cv",
+	Languages.Danish:             "da",
+	Languages.German:             "de",
+	Languages.Greek:              "el",
+	Languages.Esperanto:          "eo",
+	Languages.Spanish:            "es",
+	Languages.SpanishLatAm:       "es-la",
+	Languages.Estonian:           "et",
+	Languages.Basque:             "eu",
+	Languages.Persian:            "fa",
+	Languages.Finnish:            "fi",
+	Languages.French:             "fr",
+	Languages.Irish:              "ga",
+	Languages.Hebrew:             "he",
+	Languages.Hindi:              "hi",
+	Languages.Croatian:           "hr",
+	Languages.Hungarian:          "hu",
+	Languages.Indonesian:         "id",
+	Languages.Italian:            "it",
+	Languages.JapanRomanized:     "ja-ro",
+	Languages.Javanese:           "jv",
+	Languages.Georgian:           "ka",
+	Languages.Kazakh:             "kk",
+	Languages.KoreanRomanized:    "ko-ro",
+	Languages.Latin:              "la",
+	Languages.Lithuanian:         "lt",
+	Languages.Mongolian:          "mn",
+	Languages.Malay:              "ms",
+	Languages.Burmese:            "my",
+	Languages.Nepali:             "ne",
+	Languages.Dutch:              "nl",
+	Languages.Norwegian:          "no",
+	Languages.Polish:             "pl",
+	Languages.Portuguese:         "pt",
+	Languages.PortugueseBrazil:   "pt-br",
+	Languages.Romanian:           "ro",
+	Languages.Russian:            "ru",
+	Languages.Slovak:             "sk",
+	Languages.Albanian:           "sq",
+	Languages.Serbian:            "sr",
+	Languages.Swedish:            "sv",
+	Languages.Tamil:              "ta",
+	Languages.Telugu:             "te",
+	Languages.Thai:               "th",
+	Languages.Filipino:           "tl",
+	Languages.Turkish:            "tr",
+	Languages.Ukrainian:          "uk",
+	Languages.Urdu:               "ur",
+	Languages.Uzbek:              "uz",
+	Languages.Vietnamese:         "vi",
+	Languages.ChineseTraditional: "zh-hk",
+	Languages.ChineseRomanized:   "zh-ro",
 }
 
 func (self Language) String() string {
-	switch self {
-	case Languages.Japan:
-		return "ja"
-	case Languages.Chinese:
-		return "zh"
-	case Languages.Korean:
-		return "ko"
-	default:
-		return "en"
+	if code, ok := languageCodes[self]; ok {
+		return code
+	}
+	return "en"
+}
+
+// IsValid reports whether the language is known by MangaDex
+func (self Language) IsValid() bool {
+	_, ok := languageCodes[self]
+	return ok
+}
+
+// ParseLanguage returns the language of the MangaDex code
+func ParseLanguage(code string) (Language, bool) {
+	for language, v := range languageCodes {
+		if v == code {
+			return language, true
+		}
 	}
+	return 0, false
 }
@@ -36,6 +36,20 @@ var PageScales = struct {
 	StretchHeight: 8,
 }
 
+// PageQuality decides whether the original or the compressed pages are read and cached
+type PageQuality int
+
+var PageQualities = struct {
+	Original,
+	DataSaver,
+	// Compressed pages are used when the device saves data or is on a cellular connection
+	Auto PageQuality
+}{
+	Original:  0,
+	DataSaver: 1,
+	Auto:      2,
+}
+
 type SidebarPosition int
 
 var SidebarPositions = struct {
@@ -50,6 +50,8 @@ func (self Sort) String() string {
 		return "volume"
 	case Sorts.Chapter:
 		return "chapter"
+	case Sorts.UnreadedChapters:
+		return "unreadedChapters"
 	default:
 		return "title"
 	}
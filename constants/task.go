@@ -1,72 +1,182 @@
 package constants
 
+import (
+	"reflect"
+	"strconv"
+)
+
 type Task int
 
 var Tasks = struct {
 	GetManga,
 	UpdateManga,
 	FollowManga,
-	UnfollowManga Task
+	UnfollowManga,
+	SetUpdateMode,
+	SetMangaLanguages,
+	SetMangaGroups Task
 
 	GetChapter,
 	UpdateChapter Task
 	GetChapters,
-	UpdateChapters Task
+	UpdateChapters,
+	GetBestChapters Task
 
 	ReadPage,
 	ReadChapter,
 	UnreadChapter,
 	GetPages,
 
+	GetAuthor,
+	GetTag Task
+
 	Library,
 	Browse,
 	Tags,
 	Updates,
-	History Task
+	History,
+	Search Task
 
 	GetPrefs,
 	GetBrowsePreference,
 	GetLibraryPreference,
-	GetReaderPreference Task
+	GetReaderPreference,
+	GetNetworkPreference,
+	GetFeedPreference,
+	GetEmailPreference,
+	GetGroupPreference Task
 
 	UpdateBrowsePreference,
 	UpdateLibraryPreference,
-	UpdateReaderPreference Task
+	UpdateReaderPreference,
+	UpdateNetworkPreference,
+	UpdateFeedPreference,
+	UpdateEmailPreference,
+	UpdateGroupPreference Task
 
 	UpdateLibrary,
-	GetUpdateLibraryState Task
+	GetUpdateLibraryState,
+	CancelUpdateLibrary,
+	PauseUpdateLibrary,
+	ResumeUpdateLibrary,
+	GetUpdateHistory,
+	GetUpdateRun,
+	RetryFailedUpdates Task
+
+	GetNetworkState Task
+
+	GetWebhooks,
+	SaveWebhook,
+	DeleteWebhook,
+	TestWebhook,
+	GetWebhookLog Task
+
+	GetPushKey,
+	GetPushSubscriptions,
+	SubscribePush,
+	UnsubscribePush Task
+
+	SendDigest,
+	TestEmail Task
+
+	GetEntityFollows,
+	FollowEntity,
+	UnfollowEntity,
+	GetFollowedReleases,
+	CheckFollows Task
 }{
 	// Send and receive tasks
-	GetManga:      1,
-	UpdateManga:   2,
-	FollowManga:   3,
-	UnfollowManga: 4,
+	GetManga:          1,
+	UpdateManga:       2,
+	FollowManga:       3,
+	UnfollowManga:     4,
+	SetUpdateMode:     15,
+	SetMangaLanguages: 16,
+	SetMangaGroups:    18,
 
-	GetChapter:     5,
-	UpdateChapter:  6,
-	GetChapters:    7,
-	UpdateChapters: 8,
+	GetChapter:      5,
+	UpdateChapter:   6,
+	GetChapters:     7,
+	UpdateChapters:  8,
+	GetBestChapters: 17,
 
 	ReadPage:      9,
 	ReadChapter:   10,
 	UnreadChapter: 11,
 	GetPages:      12,
 
+	GetAuthor: 13,
+	GetTag:    14,
+
 	Library: 30,
 	Browse:  31,
 	Tags:    32,
 	Updates: 33,
 	History: 34,
+	Search:  35,
 
 	GetPrefs:             40,
 	GetBrowsePreference:  41,
 	GetLibraryPreference: 42,
 	GetReaderPreference:  43,
+	GetNetworkPreference: 44,
+	GetFeedPreference:    45,
+	GetEmailPreference:   46,
+	GetGroupPreference:   47,
 
 	UpdateBrowsePreference:  51,
 	UpdateLibraryPreference: 52,
 	UpdateReaderPreference:  53,
+	UpdateNetworkPreference: 54,
+	UpdateFeedPreference:    55,
+	UpdateEmailPreference:   56,
+	UpdateGroupPreference:   57,
 
 	UpdateLibrary:         60,
 	GetUpdateLibraryState: 61,
+	CancelUpdateLibrary:   62,
+	PauseUpdateLibrary:    63,
+	ResumeUpdateLibrary:   64,
+	GetUpdateHistory:      65,
+	GetUpdateRun:          66,
+	RetryFailedUpdates:    67,
+
+	GetNetworkState: 70,
+
+	GetWebhooks:   80,
+	SaveWebhook:   81,
+	DeleteWebhook: 82,
+	TestWebhook:   83,
+	GetWebhookLog: 84,
+
+	GetPushKey:           90,
+	GetPushSubscriptions: 91,
+	SubscribePush:        92,
+	UnsubscribePush:      93,
+
+	SendDigest: 100,
+	TestEmail:  101,
+
+	GetEntityFollows:    110,
+	FollowEntity:        111,
+	UnfollowEntity:      112,
+	GetFollowedReleases: 113,
+	CheckFollows:        114,
+}
+
+// Names of the tasks by their value, the names of their fields in Tasks
+var taskNames = func() map[Task]string {
+	names := make(map[Task]string)
+	rv, rt := reflect.ValueOf(Tasks), reflect.TypeOf(Tasks)
+	for i := 0; i < rv.NumField(); i++ {
+		names[Task(rv.Field(i).Int())] = rt.Field(i).Name
+	}
+	return names
+}()
+
+func (self Task) String() string {
+	if name, ok := taskNames[self]; ok {
+		return name
+	}
+	return strconv.Itoa(int(self))
 }
@@ -0,0 +1,25 @@
+package constants
+
+// UpdateKind is what an update retrieves from MangaDex
+type UpdateKind int
+
+var UpdateKinds = struct {
+	All,
+	Chapters,
+	Metadata UpdateKind
+}{
+	All:      0,
+	Chapters: 1,
+	Metadata: 2,
+}
+
+func (self UpdateKind) String() string {
+	switch self {
+	case UpdateKinds.Chapters:
+		return "chapters"
+	case UpdateKinds.Metadata:
+		return "metadata"
+	default:
+		return "all"
+	}
+}
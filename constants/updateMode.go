@@ -0,0 +1,15 @@
+package constants
+
+type UpdateMode int
+
+var UpdateModes = struct {
+	Auto,
+	Never,
+	Daily,
+	Weekly UpdateMode
+}{
+	Auto:   0,
+	Never:  1,
+	Daily:  2,
+	Weekly: 3,
+}
@@ -0,0 +1,17 @@
+package constants
+
+type UpdateStatus int
+
+var UpdateStatuses = struct {
+	Queued,
+	Updating,
+	Updated,
+	Failed,
+	Cancelled UpdateStatus
+}{
+	Queued:    0,
+	Updating:  1,
+	Updated:   2,
+	Failed:    3,
+	Cancelled: 4,
+}
@@ -1,9 +1,3 @@
 package constants
 
-var AssetsBaseURL = struct {
-	MangaDex string
-}{
-	MangaDex: "https://uploads.mangadex.org",
-}
-
 var CacheDirectory = "./cache"
@@ -6,31 +6,55 @@ import (
 	"sync"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"github.com/rs1703/logger"
 )
 
 var DB *sqlx.DB
 var once = sync.Once{}
 
+// FTS reports whether SQLite has been compiled with FTS5,
+// full-text search falls back to LIKE queries when it is not.
+var FTS bool
+
 //go:embed schema.sql
 var schema []byte
 
+//go:embed fts.sql
+var ftsSchema []byte
+
 func Init() {
 	once.Do(func() {
-		var err error
-		DB, err = sqlx.Open("sqlite3", "nonbiri.db?cache=shared&_journal=WAL")
-		if err != nil {
-			logger.Err.Fatalln(err)
-		}
+		DB = sqlx.NewDb(sql.OpenDB(&connector{
+			dsn:    "nonbiri.db?cache=shared&_journal=WAL",
+			driver: &sqlite3.SQLiteDriver{},
+		}), "sqlite3")
 
 		if err := DB.Ping(); err != nil {
 			logger.Err.Fatalln(err)
 		}
 		DB.MustExec(string(schema))
+
+		if err := migrate(); err != nil {
+			logger.Err.Fatalln(err)
+		}
+
+		if _, err := DB.Exec(string(ftsSchema)); err != nil {
+			logger.Err.Println("Full-text search is disabled:", err)
+		} else {
+			FTS = true
+		}
 	})
 }
 
+// Close writes the write-ahead log back into the database file and closes the database
+func Close() error {
+	if _, err := DB.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		logger.Err.Println(err)
+	}
+	return DB.Close()
+}
+
 type NamedExecFn func(query string, arg any) (sql.Result, error)
 
 func NamedExec(tx *sqlx.Tx) (fn NamedExecFn) {
@@ -40,3 +64,13 @@ func NamedExec(tx *sqlx.Tx) (fn NamedExecFn) {
 	}
 	return
 }
+
+type ExecFn func(query string, args ...any) (sql.Result, error)
+
+func Exec(tx *sqlx.Tx) (fn ExecFn) {
+	fn = DB.Exec
+	if tx != nil {
+		fn = tx.Exec
+	}
+	return
+}
@@ -0,0 +1,10 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS manga_fts USING fts5(
+  id UNINDEXED,
+  title,
+  altTitles,
+  description,
+  authors,
+  artists,
+  tags,
+  tokenize = "unicode61 remove_diacritics 2"
+);
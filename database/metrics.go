@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"nonbiri/utils/metrics"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Queries are labelled by their first keyword so the series stay few
+var queryDuration = metrics.NewHistogram("nonbiri_db_query_duration_seconds",
+	"Time spent running the database queries by statement.", nil, "statement")
+
+var timedStatements = []string{"select", "insert", "update", "delete", "replace"}
+
+// connector opens the connections of the SQLite driver with their queries timed
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c *connector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+type timedConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	defer queryDuration.Since(time.Now(), statementOf(query))
+	return c.SQLiteConn.ExecContext(ctx, query, args)
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	defer queryDuration.Since(time.Now(), statementOf(query))
+	return c.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.SQLiteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{stmt.(*sqlite3.SQLiteStmt), statementOf(query)}, nil
+}
+
+type timedStmt struct {
+	*sqlite3.SQLiteStmt
+	statement string
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	defer queryDuration.Since(time.Now(), s.statement)
+	return s.SQLiteStmt.ExecContext(ctx, args)
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	defer queryDuration.Since(time.Now(), s.statement)
+	return s.SQLiteStmt.QueryContext(ctx, args)
+}
+
+// statementOf returns the first keyword of the query, other when it is not one of timedStatements
+func statementOf(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) > 0 {
+		keyword := strings.ToLower(fields[0])
+		for _, s := range timedStatements {
+			if keyword == s {
+				return s
+			}
+		}
+	}
+	return "other"
+}
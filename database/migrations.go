@@ -0,0 +1,125 @@
+package database
+
+import (
+	"fmt"
+
+	. "nonbiri/constants"
+	"nonbiri/utils"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Migration func(tx *sqlx.Tx) error
+
+// Migrations are applied in order on top of schema.sql,
+// the number of applied migrations is stored as PRAGMA user_version.
+//
+// Columns must be added here instead of schema.sql,
+// tables which do not exist yet belong to schema.sql.
+var migrations = []Migration{
+	execMigration(`ALTER TABLE manga ADD COLUMN altTitles BLOB DEFAULT "[]"`),
+	backfillRelations,
+	execMigration(`ALTER TABLE tag ADD COLUMN tagGroup VARCHAR(255) DEFAULT ""`),
+	execMigration(`ALTER TABLE tag ADD COLUMN description TEXT DEFAULT ""`),
+	execMigration(`ALTER TABLE manga ADD COLUMN lastChecked INT DEFAULT 0`),
+	execMigration(`ALTER TABLE manga ADD COLUMN nextCheck INT DEFAULT 0`),
+	execMigration(`ALTER TABLE manga ADD COLUMN updateMode INT DEFAULT 0`),
+	execMigration(`ALTER TABLE update_run ADD COLUMN kind INT DEFAULT 0`),
+	execMigration(`ALTER TABLE manga ADD COLUMN languages BLOB DEFAULT "[]"`),
+	execMigration(`ALTER TABLE manga ADD COLUMN preferredGroups BLOB DEFAULT "[]"`),
+	execMigration(`ALTER TABLE chapter ADD COLUMN pagesDataSaver BLOB DEFAULT "[]"`),
+	execMigration(`ALTER TABLE manga ADD COLUMN checkFailures INT DEFAULT 0`),
+	execMigration(`ALTER TABLE manga ADD COLUMN unavailable BOOLEAN DEFAULT false`),
+}
+
+func execMigration(q string) Migration {
+	return func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(q)
+		return err
+	}
+}
+
+// backfillRelations fills author, manga_author and manga_tag
+// from the JSON columns of the manga table.
+func backfillRelations(tx *sqlx.Tx) error {
+	rows, err := tx.Queryx("SELECT id, authors, artists, tags FROM manga")
+	if err != nil {
+		return err
+	}
+
+	type entity struct{ ID, Name string }
+	type row struct {
+		ID      string
+		Authors []byte
+		Artists []byte
+		Tags    []byte
+	}
+
+	var entries []row
+	for rows.Next() {
+		var r row
+		if err := rows.StructScan(&r); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, r)
+	}
+	rows.Close()
+
+	for _, r := range entries {
+		for role, buf := range map[Entity][]byte{Entities.Author: r.Authors, Entities.Artist: r.Artists} {
+			var authors []entity
+			if err := utils.Unmarshal(buf, &authors); err != nil {
+				continue
+			}
+			for _, a := range authors {
+				if _, err := tx.Exec("INSERT OR IGNORE INTO author (id, name) VALUES (?, ?)", a.ID, a.Name); err != nil {
+					return err
+				}
+				if _, err := tx.Exec("INSERT OR IGNORE INTO manga_author (mangaId, authorId, role) VALUES (?, ?, ?)", r.ID, a.ID, role); err != nil {
+					return err
+				}
+			}
+		}
+
+		var tags []string
+		if err := utils.Unmarshal(r.Tags, &tags); err != nil {
+			continue
+		}
+		for _, name := range tags {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO manga_tag (mangaId, tagId) SELECT ?, id FROM tag WHERE name = ?", r.ID, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func migrate() error {
+	var version int
+	if err := DB.Get(&version, "PRAGMA user_version"); err != nil {
+		return err
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := DB.Beginx()
+		if err != nil {
+			return err
+		}
+
+		if err := migrations[version](tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -76,4 +76,140 @@ CREATE TABLE IF NOT EXISTS tag (
 );
 
 CREATE UNIQUE INDEX IF NOT EXISTS tag_id_idx ON tag (id);
-CREATE UNIQUE INDEX IF NOT EXISTS tag_name_idx ON tag (name);
\ No newline at end of file
+CREATE UNIQUE INDEX IF NOT EXISTS tag_name_idx ON tag (name);
+
+CREATE TABLE IF NOT EXISTS author (
+  id VARCHAR(36) PRIMARY KEY,
+  name VARCHAR(255) DEFAULT ""
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS author_id_idx ON author (id);
+CREATE INDEX IF NOT EXISTS author_name_idx ON author (name);
+
+CREATE TABLE IF NOT EXISTS manga_author (
+  mangaId VARCHAR(36) NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+  authorId VARCHAR(36) NOT NULL REFERENCES author(id) ON DELETE CASCADE,
+  role VARCHAR(36) NOT NULL,
+
+  PRIMARY KEY (mangaId, authorId, role)
+);
+
+CREATE INDEX IF NOT EXISTS manga_author_authorId_idx ON manga_author (authorId);
+
+CREATE TABLE IF NOT EXISTS manga_tag (
+  mangaId VARCHAR(36) NOT NULL REFERENCES manga(id) ON DELETE CASCADE,
+  tagId VARCHAR(36) NOT NULL REFERENCES tag(id) ON DELETE CASCADE,
+
+  PRIMARY KEY (mangaId, tagId)
+);
+
+CREATE INDEX IF NOT EXISTS manga_tag_tagId_idx ON manga_tag (tagId);
+
+CREATE TABLE IF NOT EXISTS queue (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task INT NOT NULL,
+  body VARCHAR(255) DEFAULT "",
+  createdAt INT DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS queue_task_body_idx ON queue (task, body);
+
+CREATE TABLE IF NOT EXISTS update_run (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  startedAt INT DEFAULT 0,
+  endedAt INT DEFAULT 0,
+  scheduled BOOLEAN DEFAULT 0,
+  cancelled BOOLEAN DEFAULT 0,
+  total INT DEFAULT 0,
+  newChapters INT DEFAULT 0,
+  failed INT DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS update_entry (
+  runId INTEGER NOT NULL REFERENCES update_run(id) ON DELETE CASCADE,
+  mangaId VARCHAR(36) NOT NULL,
+  title VARCHAR(255) DEFAULT "",
+  status INT DEFAULT 0,
+  newChapters INT DEFAULT 0,
+  attempts INT DEFAULT 0,
+  error TEXT DEFAULT "",
+  failure INT DEFAULT 0,
+  PRIMARY KEY (runId, mangaId)
+);
+
+CREATE INDEX IF NOT EXISTS update_entry_mangaId_idx ON update_entry (mangaId);
+
+CREATE TABLE IF NOT EXISTS update_schedule (
+  id VARCHAR(255) PRIMARY KEY,
+  lastRun INT DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS webhook (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  url TEXT NOT NULL,
+  events BLOB DEFAULT "[]",
+  template TEXT DEFAULT "",
+  contentType VARCHAR(255) DEFAULT "",
+  secret VARCHAR(255) DEFAULT "",
+  enabled BOOLEAN DEFAULT 1,
+  createdAt INT DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  webhookId INTEGER NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+  event INT DEFAULT 0,
+  body TEXT DEFAULT "",
+  status INT DEFAULT 0,
+  statusCode INT DEFAULT 0,
+  attempts INT DEFAULT 0,
+  error TEXT DEFAULT "",
+  createdAt INT DEFAULT 0,
+  deliveredAt INT DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhookId_idx ON webhook_delivery (webhookId);
+
+CREATE TABLE IF NOT EXISTS push_subscription (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  endpoint TEXT NOT NULL UNIQUE,
+  p256dh VARCHAR(255) NOT NULL,
+  auth VARCHAR(255) NOT NULL,
+  mangaIds BLOB DEFAULT "[]",
+  followStates BLOB DEFAULT "[]",
+  createdAt INT DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS update_chapter (
+  runId INTEGER NOT NULL REFERENCES update_run(id) ON DELETE CASCADE,
+  chapterId VARCHAR(36) NOT NULL,
+  createdAt INT DEFAULT 0,
+  PRIMARY KEY (runId, chapterId)
+);
+
+CREATE INDEX IF NOT EXISTS update_chapter_createdAt_idx ON update_chapter (createdAt);
+
+CREATE TABLE IF NOT EXISTS follow (
+  id VARCHAR(36) PRIMARY KEY,
+  type VARCHAR(36) NOT NULL,
+  name VARCHAR(255) DEFAULT "",
+  followedAt INT DEFAULT 0,
+  lastChecked INT DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS follow_release (
+  id VARCHAR(36) NOT NULL,
+  followId VARCHAR(36) NOT NULL REFERENCES follow(id) ON DELETE CASCADE,
+  mangaId VARCHAR(36) NOT NULL,
+  mangaTitle VARCHAR(255) DEFAULT "",
+  cover VARCHAR(255) DEFAULT "",
+  title VARCHAR(255) DEFAULT "",
+  volume VARCHAR(255) DEFAULT "",
+  chapter VARCHAR(255) DEFAULT "",
+  language INT DEFAULT 0,
+  publishAt INT DEFAULT 0,
+  createdAt INT DEFAULT 0,
+  PRIMARY KEY (id, followId)
+);
+
+CREATE INDEX IF NOT EXISTS follow_release_createdAt_idx ON follow_release (createdAt);
\ No newline at end of file
@@ -0,0 +1,10 @@
+package handlers
+
+import (
+	"nonbiri/services"
+	"nonbiri/websocket"
+)
+
+func GetAuthor(message *websocket.IncomingMessage) (any, error) {
+	return services.GetAuthor(message.Body.(string))
+}
@@ -11,5 +11,5 @@ func Browse(message *websocket.IncomingMessage) (any, error) {
 	if err := utils.Unmarshal(message.Body, &o); err != nil {
 		return nil, err
 	}
-	return services.Browse(o)
+	return services.Browse(message.Context(), o)
 }
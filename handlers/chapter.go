@@ -10,17 +10,21 @@ func GetChapter(message *websocket.IncomingMessage) (any, error) {
 }
 
 func UpdateChapter(message *websocket.IncomingMessage) (any, error) {
-	return services.UpdateChapter(message.Body.(string))
+	return services.UpdateChapter(message.Context(), message.Body.(string))
 }
 
 func GetChapters(message *websocket.IncomingMessage) (any, error) {
 	return services.GetChapters(message.Body.(string))
 }
 
+func GetBestChapters(message *websocket.IncomingMessage) (any, error) {
+	return services.GetBestChapters(message.Body.(string))
+}
+
 func UpdateChapters(message *websocket.IncomingMessage) (any, error) {
-	return services.UpdateChapters(message.Body.(string), false)
+	return services.UpdateChapters(message.Context(), message.Body.(string), false)
 }
 
 func GetPages(message *websocket.IncomingMessage) (any, error) {
-	return services.GetPages(message.Body.(string))
+	return services.GetPages(message.Context(), message.Body.(string))
 }
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"nonbiri/services"
+	"nonbiri/websocket"
+)
+
+func SendDigest(message *websocket.IncomingMessage) (any, error) {
+	return services.SendDigest()
+}
+
+func TestEmail(message *websocket.IncomingMessage) (any, error) {
+	return nil, services.TestEmail()
+}
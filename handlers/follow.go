@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"nonbiri/models/follow"
+	"nonbiri/services"
+	"nonbiri/utils"
+	"nonbiri/websocket"
+)
+
+func GetEntityFollows(message *websocket.IncomingMessage) (any, error) {
+	return services.GetEntityFollows(), nil
+}
+
+func FollowEntity(message *websocket.IncomingMessage) (any, error) {
+	body := &follow.Follow{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.FollowEntity(body)
+}
+
+func UnfollowEntity(message *websocket.IncomingMessage) (any, error) {
+	return nil, services.UnfollowEntity(message.Body.(string))
+}
+
+func GetFollowedReleases(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if message.Body != nil {
+		if err := utils.Unmarshal(message.Body, body); err != nil {
+			return nil, err
+		}
+	}
+	return services.GetFollowedReleases(int(body.Limit)), nil
+}
+
+func CheckFollows(message *websocket.IncomingMessage) (any, error) {
+	return services.CheckFollows(message.Context())
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	. "nonbiri/constants"
+	"nonbiri/models/entity"
 	"nonbiri/websocket"
 )
 
@@ -10,9 +11,15 @@ type H struct {
 	ChapterId   string   `json:"chapterId"`
 	ChapterIds  []string `json:"chapterIds"`
 	Page        uint16
-	FollowState FollowState `json:"followState"`
+	FollowState FollowState  `json:"followState"`
+	UpdateMode  UpdateMode   `json:"updateMode"`
+	Languages   []Language   `json:"languages"`
+	Groups      entity.Slice `json:"groups"`
 	Limit       uint16
-	PublishAt   int64 `json:"publishAt"`
+	PublishAt   int64  `json:"publishAt"`
+	RunID       int64  `json:"runId"`
+	WebhookID   int64  `json:"webhookId"`
+	Endpoint    string `json:"endpoint"`
 }
 
 func init() {
@@ -20,32 +27,76 @@ func init() {
 	websocket.Handle(Tasks.UpdateManga, UpdateManga)
 	websocket.Handle(Tasks.FollowManga, FollowManga)
 	websocket.Handle(Tasks.UnfollowManga, UnfollowManga)
+	websocket.Handle(Tasks.SetUpdateMode, SetUpdateMode)
+	websocket.Handle(Tasks.SetMangaLanguages, SetMangaLanguages)
+	websocket.Handle(Tasks.SetMangaGroups, SetMangaGroups)
 
 	websocket.Handle(Tasks.GetChapter, GetChapter)
 	websocket.Handle(Tasks.UpdateChapter, UpdateChapter)
 	websocket.Handle(Tasks.GetChapters, GetChapters)
 	websocket.Handle(Tasks.UpdateChapters, UpdateChapters)
+	websocket.Handle(Tasks.GetBestChapters, GetBestChapters)
 
 	websocket.Handle(Tasks.ReadPage, ReadPage)
 	websocket.Handle(Tasks.ReadChapter, ReadChapter)
 	websocket.Handle(Tasks.UnreadChapter, UnreadChapter)
 	websocket.Handle(Tasks.GetPages, GetPages)
 
+	websocket.Handle(Tasks.GetAuthor, GetAuthor)
+	websocket.Handle(Tasks.GetTag, GetTag)
+
 	websocket.Handle(Tasks.Library, Library)
 	websocket.Handle(Tasks.Browse, Browse)
 	websocket.Handle(Tasks.Tags, Tags)
 	websocket.Handle(Tasks.Updates, Updates)
 	websocket.Handle(Tasks.History, History)
+	websocket.Handle(Tasks.Search, Search)
 
 	websocket.Handle(Tasks.GetPrefs, GetPrefs)
 	websocket.Handle(Tasks.GetBrowsePreference, GetBrowsePreference)
 	websocket.Handle(Tasks.GetLibraryPreference, GetLibraryPreference)
 	websocket.Handle(Tasks.GetReaderPreference, GetReaderPreference)
+	websocket.Handle(Tasks.GetNetworkPreference, GetNetworkPreference)
+	websocket.Handle(Tasks.GetFeedPreference, GetFeedPreference)
+	websocket.Handle(Tasks.GetEmailPreference, GetEmailPreference)
+	websocket.Handle(Tasks.GetGroupPreference, GetGroupPreference)
 
 	websocket.Handle(Tasks.UpdateBrowsePreference, UpdateBrowsePreference)
 	websocket.Handle(Tasks.UpdateLibraryPreference, UpdateLibraryPreference)
 	websocket.Handle(Tasks.UpdateReaderPreference, UpdateReaderPreference)
+	websocket.Handle(Tasks.UpdateNetworkPreference, UpdateNetworkPreference)
+	websocket.Handle(Tasks.UpdateFeedPreference, UpdateFeedPreference)
+	websocket.Handle(Tasks.UpdateEmailPreference, UpdateEmailPreference)
+	websocket.Handle(Tasks.UpdateGroupPreference, UpdateGroupPreference)
 
 	websocket.Handle(Tasks.UpdateLibrary, UpdateLibrary)
 	websocket.Handle(Tasks.GetUpdateLibraryState, GetUpdateLibraryState)
+	websocket.Handle(Tasks.CancelUpdateLibrary, CancelUpdateLibrary)
+	websocket.Handle(Tasks.PauseUpdateLibrary, PauseUpdateLibrary)
+	websocket.Handle(Tasks.ResumeUpdateLibrary, ResumeUpdateLibrary)
+	websocket.Handle(Tasks.GetUpdateHistory, GetUpdateHistory)
+	websocket.Handle(Tasks.GetUpdateRun, GetUpdateRun)
+	websocket.Handle(Tasks.RetryFailedUpdates, RetryFailedUpdates)
+
+	websocket.Handle(Tasks.GetNetworkState, GetNetworkState)
+
+	websocket.Handle(Tasks.GetWebhooks, GetWebhooks)
+	websocket.Handle(Tasks.SaveWebhook, SaveWebhook)
+	websocket.Handle(Tasks.DeleteWebhook, DeleteWebhook)
+	websocket.Handle(Tasks.TestWebhook, TestWebhook)
+	websocket.Handle(Tasks.GetWebhookLog, GetWebhookLog)
+
+	websocket.Handle(Tasks.GetPushKey, GetPushKey)
+	websocket.Handle(Tasks.GetPushSubscriptions, GetPushSubscriptions)
+	websocket.Handle(Tasks.SubscribePush, SubscribePush)
+	websocket.Handle(Tasks.UnsubscribePush, UnsubscribePush)
+
+	websocket.Handle(Tasks.SendDigest, SendDigest)
+	websocket.Handle(Tasks.TestEmail, TestEmail)
+
+	websocket.Handle(Tasks.GetEntityFollows, GetEntityFollows)
+	websocket.Handle(Tasks.FollowEntity, FollowEntity)
+	websocket.Handle(Tasks.UnfollowEntity, UnfollowEntity)
+	websocket.Handle(Tasks.GetFollowedReleases, GetFollowedReleases)
+	websocket.Handle(Tasks.CheckFollows, CheckFollows)
 }
@@ -2,17 +2,60 @@ package handlers
 
 import (
 	"nonbiri/services"
+	"nonbiri/utils"
 	"nonbiri/websocket"
 )
 
 func Library(message *websocket.IncomingMessage) (any, error) {
-	return services.Library(false), nil
+	if message.Body == nil {
+		return services.Library(false), nil
+	}
+
+	var o services.LibraryQuery
+	if err := utils.Unmarshal(message.Body, &o); err != nil {
+		return nil, err
+	}
+	return services.FilterLibrary(o)
 }
 
 func UpdateLibrary(message *websocket.IncomingMessage) (any, error) {
-	return services.UpdateLibrary(), nil
+	return services.UpdateLibrary()
 }
 
 func GetUpdateLibraryState(message *websocket.IncomingMessage) (any, error) {
 	return services.GetUpdateLibraryState(), nil
 }
+
+func CancelUpdateLibrary(message *websocket.IncomingMessage) (any, error) {
+	return services.CancelUpdateLibrary()
+}
+
+func PauseUpdateLibrary(message *websocket.IncomingMessage) (any, error) {
+	return services.PauseUpdateLibrary()
+}
+
+func ResumeUpdateLibrary(message *websocket.IncomingMessage) (any, error) {
+	return services.ResumeUpdateLibrary()
+}
+
+func GetUpdateHistory(message *websocket.IncomingMessage) (any, error) {
+	return services.GetUpdateHistory(), nil
+}
+
+func GetUpdateRun(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.GetUpdateRun(body.RunID)
+}
+
+func RetryFailedUpdates(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if message.Body != nil {
+		if err := utils.Unmarshal(message.Body, body); err != nil {
+			return nil, err
+		}
+	}
+	return services.RetryFailedUpdates(body.RunID)
+}
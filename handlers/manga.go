@@ -11,7 +11,7 @@ func GetManga(message *websocket.IncomingMessage) (any, error) {
 }
 
 func UpdateManga(message *websocket.IncomingMessage) (any, error) {
-	return services.UpdateManga(message.Body.(string), false)
+	return services.UpdateManga(message.Context(), message.Body.(string), false)
 }
 
 func FollowManga(message *websocket.IncomingMessage) (any, error) {
@@ -25,3 +25,27 @@ func FollowManga(message *websocket.IncomingMessage) (any, error) {
 func UnfollowManga(message *websocket.IncomingMessage) (any, error) {
 	return services.UnfollowManga(message.Body.(string))
 }
+
+func SetMangaLanguages(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.SetMangaLanguages(body.MangaId, body.Languages)
+}
+
+func SetMangaGroups(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.SetMangaGroups(body.MangaId, body.Groups)
+}
+
+func SetUpdateMode(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.SetUpdateMode(body.MangaId, body.UpdateMode)
+}
@@ -0,0 +1,10 @@
+package handlers
+
+import (
+	"nonbiri/services"
+	"nonbiri/websocket"
+)
+
+func GetNetworkState(message *websocket.IncomingMessage) (any, error) {
+	return services.GetNetworkState(), nil
+}
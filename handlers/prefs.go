@@ -23,6 +23,22 @@ func GetReaderPreference(message *websocket.IncomingMessage) (any, error) {
 	return prefs.Reader, nil
 }
 
+func GetNetworkPreference(message *websocket.IncomingMessage) (any, error) {
+	return prefs.Network, nil
+}
+
+func GetFeedPreference(message *websocket.IncomingMessage) (any, error) {
+	return prefs.Feed, nil
+}
+
+func GetEmailPreference(message *websocket.IncomingMessage) (any, error) {
+	return prefs.Email, nil
+}
+
+func GetGroupPreference(message *websocket.IncomingMessage) (any, error) {
+	return prefs.Groups, nil
+}
+
 func UpdateBrowsePreference(message *websocket.IncomingMessage) (any, error) {
 	data := &prefs.BrowsePreference{}
 	if err := utils.Unmarshal(message.Body, data); err != nil {
@@ -46,3 +62,35 @@ func UpdateReaderPreference(message *websocket.IncomingMessage) (any, error) {
 	}
 	return services.UpdateReaderPref(data)
 }
+
+func UpdateNetworkPreference(message *websocket.IncomingMessage) (any, error) {
+	data := &prefs.NetworkPreference{}
+	if err := utils.Unmarshal(message.Body, data); err != nil {
+		return nil, err
+	}
+	return services.UpdateNetworkPref(data)
+}
+
+func UpdateFeedPreference(message *websocket.IncomingMessage) (any, error) {
+	data := &prefs.FeedPreference{}
+	if err := utils.Unmarshal(message.Body, data); err != nil {
+		return nil, err
+	}
+	return services.UpdateFeedPref(data)
+}
+
+func UpdateEmailPreference(message *websocket.IncomingMessage) (any, error) {
+	data := &prefs.EmailPreference{}
+	if err := utils.Unmarshal(message.Body, data); err != nil {
+		return nil, err
+	}
+	return services.UpdateEmailPref(data)
+}
+
+func UpdateGroupPreference(message *websocket.IncomingMessage) (any, error) {
+	data := &prefs.GroupPreference{}
+	if err := utils.Unmarshal(message.Body, data); err != nil {
+		return nil, err
+	}
+	return services.UpdateGroupPref(data)
+}
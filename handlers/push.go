@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"nonbiri/models/push"
+	"nonbiri/services"
+	"nonbiri/utils"
+	"nonbiri/websocket"
+)
+
+func GetPushKey(message *websocket.IncomingMessage) (any, error) {
+	return services.GetPushKey()
+}
+
+func GetPushSubscriptions(message *websocket.IncomingMessage) (any, error) {
+	return services.GetPushSubscriptions(), nil
+}
+
+func SubscribePush(message *websocket.IncomingMessage) (any, error) {
+	body := &push.Subscription{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.SubscribePush(body)
+}
+
+func UnsubscribePush(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return nil, services.UnsubscribePush(body.Endpoint)
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"nonbiri/services"
+	"nonbiri/utils"
+	"nonbiri/websocket"
+)
+
+func Search(message *websocket.IncomingMessage) (any, error) {
+	var o services.SearchQuery
+	if err := utils.Unmarshal(message.Body, &o); err != nil {
+		return nil, err
+	}
+	return services.Search(o)
+}
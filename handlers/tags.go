@@ -8,3 +8,7 @@ import (
 func Tags(message *websocket.IncomingMessage) (any, error) {
 	return services.Tags(), nil
 }
+
+func GetTag(message *websocket.IncomingMessage) (any, error) {
+	return services.GetTag(message.Body.(string))
+}
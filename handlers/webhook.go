@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"nonbiri/models/webhook"
+	"nonbiri/services"
+	"nonbiri/utils"
+	"nonbiri/websocket"
+)
+
+func GetWebhooks(message *websocket.IncomingMessage) (any, error) {
+	return services.GetWebhooks(), nil
+}
+
+func SaveWebhook(message *websocket.IncomingMessage) (any, error) {
+	body := &webhook.Input{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.SaveWebhook(body)
+}
+
+func DeleteWebhook(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return nil, services.DeleteWebhook(body.WebhookID)
+}
+
+func TestWebhook(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.TestWebhook(body.WebhookID)
+}
+
+func GetWebhookLog(message *websocket.IncomingMessage) (any, error) {
+	body := &H{}
+	if err := utils.Unmarshal(message.Body, body); err != nil {
+		return nil, err
+	}
+	return services.GetWebhookLog(body.WebhookID)
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"nonbiri/database"
 	_ "nonbiri/handlers"
+	"nonbiri/models/manga"
 	_ "nonbiri/prefs"
 	"nonbiri/services"
-
-	"nonbiri/scrapers/mangadex"
+	"nonbiri/websocket"
 
 	"github.com/rs1703/logger"
 )
@@ -17,8 +23,12 @@ var Build string
 var Version = "develop"
 var Mode string
 
+// How long running updates and downloads are waited for when the server is stopped
+var ShutdownTimeout time.Duration
+
 func init() {
 	modePtr := flag.String("mode", "release", "")
+	flag.DurationVar(&ShutdownTimeout, "shutdown-timeout", 30*time.Second, "")
 	flag.Parse()
 	Mode = *modePtr
 
@@ -27,17 +37,16 @@ func init() {
 
 func main() {
 	database.Init()
-
-	// Retrieves tags from mangadex
-	tags, err := mangadex.TagsEx()
-	if err != nil {
-		logger.Err.Fatalln(err)
+	if err := services.ApplyNetworkPref(); err != nil {
+		logger.Err.Println(err)
+	}
+	if err := manga.Reindex(); err != nil {
+		logger.Err.Println(err)
 	}
 
-	for _, tag := range tags {
-		if _, err := tag.Save(); err != nil {
-			logger.Err.Fatalln(err)
-		}
+	// Retrieves tags from mangadex, tags from the previous run are used when offline
+	if err := services.RefreshTags(context.Background()); err != nil {
+		logger.Err.Println(err)
 	}
 
 	// Prepare cache
@@ -45,6 +54,45 @@ func main() {
 	services.Library(true)
 	services.Updates(true)
 	go services.ScheduleUpdate()
+	go services.ScheduleTagRefresh()
+	go services.MonitorNetwork()
+	go services.ResumeWebhooks()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go StartServer()
+	<-ctx.Done()
+	stop()
+
+	shutdown()
+}
 
-	StartServer()
+// shutdown stops the server and the background work within ShutdownTimeout,
+// then the websocket connections and their handlers within what remains of it,
+// the database is closed last.
+func shutdown() {
+	logger.Inf.Println("Shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		StopServer(ctx)
+	}()
+
+	if err := services.Shutdown(ctx); err != nil {
+		logger.Err.Println("Running work has been aborted:", err)
+	}
+	wg.Wait()
+
+	if err := websocket.Close(ctx); err != nil {
+		logger.Err.Println("Websocket connections have not been closed in time:", err)
+	}
+	if err := database.Close(); err != nil {
+		logger.Err.Println(err)
+	}
 }
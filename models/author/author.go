@@ -0,0 +1,20 @@
+package author
+
+import (
+	"database/sql"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"nonbiri/models/entity"
+)
+
+type Author entity.Entity
+
+func One(id string) (result *Author, err error) {
+	result = &Author{}
+	if err = DB.Get(result, "SELECT * FROM author WHERE id = ?", id); err == sql.ErrNoRows {
+		err = ErrAuthorNotFound
+	}
+	return
+}
@@ -21,8 +21,10 @@ type Chapter struct {
 
 	Metadata
 
-	Pages   Pages            `json:"pages,omitempty"`
-	History *history.History `json:"history,omitempty" db:"history"`
+	Pages Pages `json:"pages,omitempty"`
+	// Compressed variants of the pages, served under /data-saver/
+	DataSaverPages Pages            `json:"dataSaverPages,omitempty" db:"pagesDataSaver"`
+	History        *history.History `json:"history,omitempty" db:"history"`
 
 	MangaTitle string `json:"mangaTitle,omitempty" db:"mangaTitle"`
 	Cover      string `json:"cover,omitempty"`
@@ -48,7 +50,16 @@ type Map map[string]*Chapter
 
 var count uint8
 
-func All(limit uint16) (result Slice, err error) {
+// All returns the latest chapters of the followed manga,
+// the ones released by the blocked groups are left out.
+func All(limit uint16, blocked []string) (result Slice, err error) {
+	var where string
+	var args []any
+	for _, id := range blocked {
+		where += " AND chapter.groups NOT LIKE ?"
+		args = append(args, groupPattern(id))
+	}
+
 	q := `SELECT
 					chapter.id,
 					chapter.mangaId,
@@ -57,6 +68,7 @@ func All(limit uint16) (result Slice, err error) {
 					chapter.title,
 					chapter.volume,	
 					chapter.chapter,
+					COALESCE(chapter.language, 0) language,
 					chapter.groups,
 					chapter.hash,
 					chapter.externalURL,
@@ -71,18 +83,23 @@ func All(limit uint16) (result Slice, err error) {
 							PARTITION BY mangaId
 							ORDER BY publishAt DESC
 						) n
-					FROM chapter
+					FROM chapter WHERE 1` + where + `
 				) chapter
 				LEFT JOIN manga ON manga.id = chapter.mangaId
 				LEFT JOIN history ON history.chapterId = chapter.id
 				WHERE n <= 3 AND manga.followed = true ORDER BY chapter.publishAt DESC
 				LIMIT ?`
-	if err = DB.Select(&result, q, limit); err != nil {
+	if err = DB.Select(&result, q, append(args, limit)...); err != nil {
 		logger.Err.Println(err)
 	}
 	return
 }
 
+// groupPattern matches the groups column of the chapters released by the group
+func groupPattern(id string) string {
+	return `%"id":"` + id + `"%`
+}
+
 func One(id string) (result *Chapter, err error) {
 	result = &Chapter{}
 	if err = DB.Get(result, "SELECT * FROM chapter WHERE id = ?", id); err == nil {
@@ -93,6 +110,15 @@ func One(id string) (result *Chapter, err error) {
 	return
 }
 
+// ByHash returns the chapter which pages are stored under the hash
+func ByHash(hash string) (result *Chapter, err error) {
+	result = &Chapter{}
+	if err = DB.Get(result, "SELECT * FROM chapter WHERE hash = ? LIMIT 1", hash); err == sql.ErrNoRows {
+		err = ErrChapterNotFound
+	}
+	return
+}
+
 func ByManga(id string) (result Slice) {
 	DB.Select(&result, "SELECT * FROM chapter WHERE mangaId = ?", id)
 	if len(result) == 0 {
@@ -133,6 +159,78 @@ func ByManga(id string) (result Slice) {
 	return
 }
 
+func ByIds(ids []string) (result Slice) {
+	if len(ids) == 0 {
+		return
+	}
+
+	q, args, err := sqlx.In("SELECT * FROM chapter WHERE id IN (?)", ids)
+	if err != nil {
+		logger.Err.Println(err)
+		return
+	}
+	if err = DB.Select(&result, DB.Rebind(q), args...); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// Downloaded returns the chapters which every page has been cached
+func Downloaded() (result Slice) {
+	hashes := CachedHashes()
+	if len(hashes) == 0 {
+		return
+	}
+
+	q, args, err := sqlx.In("SELECT * FROM chapter WHERE hash IN (?)", hashes)
+	if err != nil {
+		logger.Err.Println(err)
+		return
+	}
+
+	var cached Slice
+	if err = DB.Select(&cached, DB.Rebind(q), args...); err != nil {
+		logger.Err.Println(err)
+		return
+	}
+
+	for _, c := range cached {
+		if _, ok := c.PagePaths(); ok {
+			result = append(result, c)
+		}
+	}
+	return
+}
+
+// Discovered returns the chapters of the followed manga
+// which library updates have found within [since, until),
+// so chapters found in the second of until are left to the next call.
+func Discovered(since, until int64) (result Slice, err error) {
+	q := `SELECT DISTINCT
+					chapter.id,
+					chapter.mangaId,
+					chapter.createdAt,
+					chapter.publishAt,
+					chapter.title,
+					chapter.volume,
+					chapter.chapter,
+					COALESCE(chapter.language, 0) language,
+					chapter.groups,
+					chapter.hash,
+					chapter.externalURL,
+					manga.title mangaTitle,
+					manga.cover cover
+				FROM update_chapter discovered
+				JOIN chapter ON chapter.id = discovered.chapterId
+				JOIN manga ON manga.id = chapter.mangaId
+				WHERE discovered.createdAt >= ? AND discovered.createdAt < ? AND manga.followed = true
+				ORDER BY manga.title, chapter.publishAt`
+	if err = DB.Select(&result, q, since, until); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
 func (c *Chapter) UpdateMetadata(tx *sqlx.Tx) (sql.Result, error) {
 	return NamedExec(tx)(`
 		INSERT OR IGNORE INTO chapter (id, mangaId)
@@ -144,7 +242,7 @@ func (c *Chapter) UpdateMetadata(tx *sqlx.Tx) (sql.Result, error) {
 						volume		= :volume,		chapter 		= :chapter,
 						language	= :language,	groups 			= :groups,
 						hash			= :hash,			externalURL = :externalURL,
-						pages			= :pages
+						pages			= :pages,			pagesDataSaver = :pagesDataSaver
 		WHERE 	id				= :id`, c)
 }
 
@@ -193,6 +291,95 @@ func (s Slice) SortByChapter() {
 	})
 }
 
+// Preference orders the releases of the same chapter
+type Preference struct {
+	Languages []Language
+	// Ids of the preferred groups, ordered by priority
+	Groups []string
+}
+
+// rank returns the positions of the chapter language and its best group in the preference
+func (p *Preference) rank(c *Chapter) (language, group int) {
+	language = len(p.Languages)
+	for i, v := range p.Languages {
+		if v == c.Language {
+			language = i
+			break
+		}
+	}
+
+	group = len(p.Groups)
+	for i, id := range p.Groups {
+		if c.HasGroup(id) {
+			group = i
+			break
+		}
+	}
+	return
+}
+
+// HasGroup reports whether one of the groups released the chapter
+func (c *Chapter) HasGroup(ids ...string) bool {
+	for _, g := range c.Groups {
+		for _, id := range ids {
+			if g.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithoutGroups leaves out the chapters released by one of the groups
+func (s Slice) WithoutGroups(ids []string) (result Slice) {
+	if len(ids) == 0 {
+		return s
+	}
+	for _, c := range s {
+		if !c.HasGroup(ids...) {
+			result = append(result, c)
+		}
+	}
+	return
+}
+
+// Best keeps a single release per chapter number, the one whose language
+// comes first in the preference, then the one of the most preferred group,
+// then the latest published one. Chapters without a number are all kept.
+func (s Slice) Best(p Preference) (result Slice) {
+	indexes := make(map[string]int)
+	for _, c := range s {
+		if len(c.Chapter) == 0 {
+			result = append(result, c)
+			continue
+		}
+
+		i, exists := indexes[c.Chapter]
+		if !exists {
+			indexes[c.Chapter] = len(result)
+			result = append(result, c)
+			continue
+		}
+
+		language, group := p.rank(c)
+		prevLanguage, prevGroup := p.rank(result[i])
+
+		switch {
+		case language != prevLanguage:
+			if language < prevLanguage {
+				result[i] = c
+			}
+		case group != prevGroup:
+			if group < prevGroup {
+				result[i] = c
+			}
+		case c.PublishAt > result[i].PublishAt:
+			result[i] = c
+		}
+	}
+	return
+}
+
 func (s *Map) Slice() (result Slice) {
 	for _, m := range *s {
 		result = append(result, m)
@@ -2,12 +2,22 @@ package chapter
 
 import (
 	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"strings"
 
+	. "nonbiri/constants"
 	"nonbiri/utils"
 )
 
 type Pages []string
 
+// Directories of the cache the pages are stored in, same as their paths on MangaDex@Home
+const (
+	dataDirectory      = "data"
+	dataSaverDirectory = "data-saver"
+)
+
 func (p Pages) Value() (driver.Value, error) {
 	return utils.SliceToBytes(p)
 }
@@ -15,3 +25,60 @@ func (p Pages) Value() (driver.Value, error) {
 func (p *Pages) Scan(src any) error {
 	return utils.Unmarshal(src, p)
 }
+
+// PagePaths returns where the pages of the chapter are cached,
+// the compressed pages are returned when the original ones have not all been cached,
+// false when neither of them have all been cached yet.
+func (c *Chapter) PagePaths() ([]string, bool) {
+	if paths, ok := pagePaths(dataDirectory, c.Hash, c.Pages); ok {
+		return paths, true
+	}
+	return pagePaths(dataSaverDirectory, c.Hash, c.DataSaverPages)
+}
+
+// PagePath returns where the page is cached,
+// false when quality is neither "data" nor "data-saver" or the names are not plain file names.
+func PagePath(quality, hash, file string) (string, bool) {
+	if quality != dataDirectory && quality != dataSaverDirectory {
+		return "", false
+	}
+	for _, name := range []string{hash, file} {
+		if len(name) == 0 || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return "", false
+		}
+	}
+	return filepath.Join(CacheDirectory, quality, hash, file), true
+}
+
+func pagePaths(directory, hash string, pages Pages) ([]string, bool) {
+	if len(hash) == 0 || len(pages) == 0 {
+		return nil, false
+	}
+
+	var paths []string
+	for _, page := range pages {
+		path := filepath.Join(CacheDirectory, directory, hash, page)
+		if !utils.IsFileExists(path) {
+			return nil, false
+		}
+		paths = append(paths, path)
+	}
+	return paths, true
+}
+
+// CachedHashes returns hashes of the chapters which pages have been cached,
+// in either of the qualities.
+func CachedHashes() []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, directory := range []string{dataDirectory, dataSaverDirectory} {
+		entries, _ := os.ReadDir(filepath.Join(CacheDirectory, directory))
+		for _, entry := range entries {
+			if entry.IsDir() && !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				result = append(result, entry.Name())
+			}
+		}
+	}
+	return result
+}
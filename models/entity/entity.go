@@ -20,3 +20,19 @@ func (s Slice) Value() (driver.Value, error) {
 func (s *Slice) Scan(src any) error {
 	return utils.Unmarshal(src, s)
 }
+
+func (s Slice) Ids() (result []string) {
+	for _, e := range s {
+		result = append(result, e.ID)
+	}
+	return
+}
+
+func (s Slice) Has(id string) bool {
+	for _, e := range s {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
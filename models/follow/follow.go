@@ -0,0 +1,158 @@
+package follow
+
+import (
+	"database/sql"
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs1703/logger"
+)
+
+// Follow is a scanlation group or an author whose new releases are tracked,
+// including the ones of manga which are not in the library.
+type Follow struct {
+	ID         string `json:"id"`
+	Type       Entity `json:"type"`
+	Name       string `json:"name"`
+	FollowedAt int64  `json:"followedAt" db:"followedAt"`
+	// Releases created since then are retrieved by the next check
+	LastChecked int64 `json:"lastChecked" db:"lastChecked"`
+}
+
+// Release is a chapter of a followed group, or a manga of a followed author
+type Release struct {
+	// Chapter id for groups, manga id for authors
+	ID         string `json:"id"`
+	FollowID   string `json:"followId" db:"followId"`
+	MangaID    string `json:"mangaId" db:"mangaId"`
+	MangaTitle string `json:"mangaTitle" db:"mangaTitle"`
+	Cover      string `json:"cover"`
+
+	Title     string   `json:"title,omitempty"`
+	Volume    string   `json:"volume,omitempty"`
+	Chapter   string   `json:"chapter,omitempty"`
+	Language  Language `json:"language,omitempty"`
+	PublishAt int64    `json:"publishAt,omitempty" db:"publishAt"`
+	CreatedAt int64    `json:"createdAt" db:"createdAt"`
+
+	// Name and type of the follow, only set when listing
+	FollowName string `json:"followName,omitempty" db:"followName"`
+	FollowType Entity `json:"followType,omitempty" db:"followType"`
+}
+
+type Slice []*Follow
+type Releases []*Release
+
+func All() (result Slice) {
+	result = Slice{}
+	if err := DB.Select(&result, "SELECT * FROM follow ORDER BY name"); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+func One(id string) (result *Follow, err error) {
+	result = &Follow{}
+	if err = DB.Get(result, "SELECT * FROM follow WHERE id = ?", id); err == sql.ErrNoRows {
+		err = ErrFollowNotFound
+	}
+	return
+}
+
+// ByType returns the follows of the entity type
+func ByType(t Entity) (result Slice) {
+	if err := DB.Select(&result, "SELECT * FROM follow WHERE type = ?", t); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// Save stores the follow, the name is updated when it is already followed
+func (f *Follow) Save() error {
+	f.FollowedAt = time.Now().Unix()
+	q := `INSERT INTO follow (id, type, name, followedAt)
+				VALUES (:id, :type, :name, :followedAt)
+				ON CONFLICT (id) DO UPDATE SET name = excluded.name`
+
+	if _, err := NamedExec(nil)(q, f); err != nil {
+		return err
+	}
+	return DB.Get(f, "SELECT * FROM follow WHERE id = ?", f.ID)
+}
+
+// Delete removes the follow and its releases
+func Delete(id string) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM follow_release WHERE followId = ?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM follow WHERE id = ?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveReleases stores the new releases and the time the follows were checked at
+func SaveReleases(releases Releases, checkedAt int64, ids []string) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range releases {
+		r.CreatedAt = checkedAt
+		q := `INSERT OR IGNORE INTO follow_release
+						(id, followId, mangaId, mangaTitle, cover, title, volume, chapter, language, publishAt, createdAt)
+					VALUES
+						(:id, :followId, :mangaId, :mangaTitle, :cover, :title, :volume, :chapter, :language, :publishAt, :createdAt)`
+		if _, err := NamedExec(tx)(q, r); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if len(ids) > 0 {
+		q, args, err := sqlx.In("UPDATE follow SET lastChecked = ? WHERE id IN (?)", checkedAt, ids)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(tx.Rebind(q), args...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Latest returns the latest releases of every follow, the newest discovered first
+func Latest(limit int) (result Releases) {
+	result = Releases{}
+	q := `SELECT follow_release.*, follow.name followName, follow.type followType
+				FROM follow_release
+				JOIN follow ON follow.id = follow_release.followId
+				ORDER BY follow_release.createdAt DESC, follow_release.publishAt DESC
+				LIMIT ?`
+
+	if err := DB.Select(&result, q, limit); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// Prune deletes the releases beyond the latest keep releases
+func Prune(keep int) error {
+	q := `DELETE FROM follow_release WHERE rowid NOT IN (
+					SELECT rowid FROM follow_release ORDER BY createdAt DESC, publishAt DESC LIMIT ?
+				)`
+	_, err := DB.Exec(q, keep)
+	return err
+}
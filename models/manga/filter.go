@@ -0,0 +1,204 @@
+package manga
+
+import (
+	"fmt"
+	"strings"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Filter struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+
+	Title           string
+	Followed        bool
+	FollowStates    []FollowState
+	Statuses        []Status
+	Demographics    []Demographic
+	Origins         []Language
+	ExcludedOrigins []Language
+	Ratings         []Rating
+	IncludedTags    []string
+	ExcludedTags    []string
+	HasUnread       bool
+
+	// Hashes of the chapters that have been downloaded,
+	// nil means the downloaded state is ignored.
+	Downloaded []string
+
+	Sort  Sort
+	Order Order
+}
+
+// Cursor points to the last entry of the previous page
+type Cursor struct {
+	Value any    `json:"v"`
+	ID    string `json:"id"`
+}
+
+// Find filters and sorts manga, returns the requested page,
+// the cursor of the next page and the total of matching entries.
+func (f *Filter) Find() (result Slice, next *Cursor, total int, err error) {
+	where := []string{"TRUE"}
+	var args []any
+
+	if f.Followed {
+		where = append(where, "manga.followed = true")
+	}
+
+	if len(f.Title) > 0 {
+		where = append(where, `(manga.title LIKE ? OR EXISTS (
+			SELECT 1 FROM manga_author JOIN author ON author.id = manga_author.authorId
+			WHERE manga_author.mangaId = manga.id AND author.name LIKE ?))`)
+		like := "%" + f.Title + "%"
+		args = append(args, like, like)
+	}
+
+	in := func(column string, values any, n int) {
+		if n > 0 {
+			where = append(where, column+" IN (?)")
+			args = append(args, values)
+		}
+	}
+	in("manga.followState", f.FollowStates, len(f.FollowStates))
+	in("manga.status", f.Statuses, len(f.Statuses))
+	in("manga.demographic", f.Demographics, len(f.Demographics))
+	in("manga.origin", f.Origins, len(f.Origins))
+	in("manga.rating", f.Ratings, len(f.Ratings))
+
+	if len(f.ExcludedOrigins) > 0 {
+		where = append(where, "manga.origin NOT IN (?)")
+		args = append(args, f.ExcludedOrigins)
+	}
+
+	hasTag := `EXISTS (SELECT 1 FROM manga_tag JOIN tag ON tag.id = manga_tag.tagId
+		WHERE manga_tag.mangaId = manga.id AND (tag.id = ? OR tag.name = ?))`
+
+	for _, t := range f.IncludedTags {
+		where = append(where, hasTag)
+		args = append(args, t, t)
+	}
+
+	for _, t := range f.ExcludedTags {
+		where = append(where, "NOT "+hasTag)
+		args = append(args, t, t)
+	}
+
+	if f.Downloaded != nil {
+		if len(f.Downloaded) == 0 {
+			return
+		}
+		where = append(where, "EXISTS (SELECT 1 FROM chapter c WHERE c.mangaId = manga.id AND c.hash IN (?))")
+		args = append(args, f.Downloaded)
+	}
+
+	q := `WITH library AS (
+					SELECT manga.*,	COUNT(chapter.id) totalChapters, COUNT(history.id) readedChapters, COALESCE(MAX(chapter.publishAt), 0) latestChapterAt FROM manga
+					LEFT JOIN chapter ON chapter.mangaId = manga.id
+					LEFT JOIN history ON history.chapterId = chapter.id AND history.readed = true
+					WHERE ` + strings.Join(where, " AND ") + `
+					GROUP BY manga.id
+				)`
+
+	var having []string
+	var havingArgs []any
+
+	if f.HasUnread {
+		having = append(having, "totalChapters > readedChapters")
+	}
+
+	countQuery := q + " SELECT COUNT(*) FROM library"
+	if len(having) > 0 {
+		countQuery += " WHERE " + strings.Join(having, " AND ")
+	}
+
+	if err = selectIn(&total, true, countQuery, args...); err != nil {
+		return
+	}
+
+	key := f.sortKey()
+	op, dir := ">", "ASC"
+	if f.Order == Orders.DESC {
+		op, dir = "<", "DESC"
+	}
+
+	if f.Cursor != nil {
+		having = append(having, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", key, op))
+		havingArgs = append(havingArgs, f.Cursor.Value, f.Cursor.Value, f.Cursor.ID)
+	}
+
+	q += " SELECT * FROM library"
+	if len(having) > 0 {
+		q += " WHERE " + strings.Join(having, " AND ")
+	}
+	q += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s LIMIT ? OFFSET ?", key, dir)
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 60
+	}
+
+	args = append(args, havingArgs...)
+	args = append(args, limit+1, f.Offset)
+
+	if err = selectIn(&result, false, q, args...); err != nil {
+		return
+	}
+
+	if len(result) > limit {
+		result = result[:limit]
+		last := result[limit-1]
+		next = &Cursor{Value: f.sortValue(last), ID: last.ID}
+	}
+	return
+}
+
+func (f *Filter) sortKey() string {
+	switch f.Sort.String() {
+	case Sorts.Title.String():
+		return "title COLLATE NOCASE"
+	case "totalChapters":
+		return "totalChapters"
+	case Sorts.UnreadedChapters.String():
+		return "(totalChapters - readedChapters)"
+	case Sorts.CreatedAt.String():
+		return "createdAt"
+	case Sorts.UpdatedAt.String():
+		return "updatedAt"
+	default:
+		return "latestChapterAt"
+	}
+}
+
+func (f *Filter) sortValue(m *Manga) any {
+	switch f.Sort.String() {
+	case Sorts.Title.String():
+		return m.Title
+	case "totalChapters":
+		return m.TotalChapters
+	case Sorts.UnreadedChapters.String():
+		return int(m.TotalChapters) - int(m.ReadedChapters)
+	case Sorts.CreatedAt.String():
+		return m.CreatedAt
+	case Sorts.UpdatedAt.String():
+		return m.UpdatedAt
+	default:
+		return int64(m.LatestChapterAt)
+	}
+}
+
+func selectIn(dest any, get bool, q string, args ...any) error {
+	q, args, err := sqlx.In(q, args...)
+	if err != nil {
+		return err
+	}
+	if get {
+		return DB.Get(dest, DB.Rebind(q), args...)
+	}
+	return DB.Select(dest, DB.Rebind(q), args...)
+}
@@ -29,6 +29,20 @@ type Manga struct {
 	Followed    bool        `json:"followed"`
 	FollowState FollowState `json:"followState" db:"followState"`
 	FollowedAt  int64       `json:"followedAt" db:"followedAt"`
+
+	// Last time the chapters were retrieved from MangaDex
+	LastChecked int64 `json:"lastChecked,omitempty" db:"lastChecked"`
+	// Next time the chapters are retrieved by the scheduled updates
+	NextCheck  int64      `json:"nextCheck,omitempty" db:"nextCheck"`
+	UpdateMode UpdateMode `json:"updateMode" db:"updateMode"`
+	// Consecutive failed checks, the next check is pushed back further with every one of them
+	CheckFailures int `json:"checkFailures,omitempty" db:"checkFailures"`
+	// Not found on MangaDex, no longer checked automatically until it is checked successfully
+	Unavailable bool `json:"unavailable,omitempty" db:"unavailable"`
+	// Replaces the preferred languages when the chapters are retrieved
+	Languages LanguageList `json:"languages,omitempty"`
+	// Chosen before the globally preferred groups by the collapsed chapter view
+	PreferredGroups entity.Slice `json:"preferredGroups,omitempty" db:"preferredGroups"`
 }
 
 type Metadata struct {
@@ -36,6 +50,7 @@ type Metadata struct {
 	UpdatedAt int64 `json:"updatedAt,omitempty" db:"updatedAt"`
 
 	Title       string `json:"title"`
+	AltTitles   Titles `json:"altTitles,omitempty" db:"altTitles"`
 	Description string `json:"description,omitempty"`
 	Cover       string `json:"cover"`
 
@@ -124,16 +139,23 @@ func (m *Manga) UpdateMetadata(tx *sqlx.Tx) (sql.Result, error) {
 				
 				UPDATE 	manga
 				SET 		createdAt 	= :createdAt, 	updatedAt 	= :updatedAt,
-								title 			= :title, 			description = :description,
+								title 			= :title, 			altTitles 	= :altTitles,
+								description = :description, banner 			= :banner,
 								cover 			= :cover, 			authors 		= :authors,
 								artists 		= :artists, 		tags 				= :tags,
 								links 			= :links, 			relateds 		= :relateds,
 								demographic = :demographic, origin 			= :origin,
-								rating 			= :rating, 			status 			= :status,
-								banner 			= :banner
+								rating 			= :rating, 			status 			= :status
 				WHERE 	id 					= :id`
 
-	return NamedExec(tx)(q, m)
+	result, err := NamedExec(tx)(q, m)
+	if err == nil {
+		err = m.updateRelations(tx)
+	}
+	if err == nil {
+		err = m.index(tx)
+	}
+	return result, err
 }
 
 func (m *Manga) UpdateFollowState(tx *sqlx.Tx) (sql.Result, error) {
@@ -146,6 +168,22 @@ func (m *Manga) UpdateFollowState(tx *sqlx.Tx) (sql.Result, error) {
 	return NamedExec(tx)(q, m)
 }
 
+// SetLastChecked stores the time the chapters of the specified manga were retrieved,
+// their failed checks are forgotten.
+func SetLastChecked(tx *sqlx.Tx, at int64, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	q, args, err := sqlx.In(`UPDATE manga SET lastChecked = ?, checkFailures = 0, unavailable = false
+		WHERE id IN (?)`, at, ids)
+	if err != nil {
+		return err
+	}
+	_, err = Exec(tx)(DB.Rebind(q), args...)
+	return err
+}
+
 func (s Slice) Map() (result Map) {
 	result = make(Map)
 	for _, m := range s {
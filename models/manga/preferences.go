@@ -0,0 +1,42 @@
+package manga
+
+import (
+	"database/sql"
+	"database/sql/driver"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+	"nonbiri/utils"
+)
+
+// LanguageList overrides the preferred languages, ordered by priority
+type LanguageList []Language
+
+func (l LanguageList) Value() (driver.Value, error) {
+	return utils.SliceToBytes(l)
+}
+
+func (l *LanguageList) Scan(src any) error {
+	return utils.Unmarshal(src, l)
+}
+
+// ChapterPreferences returns the manga with only its languages
+// and preferred groups, which are empty when they are not overridden.
+func ChapterPreferences(id string) (result *Manga, err error) {
+	result = &Manga{}
+	err = DB.Get(result, "SELECT id, languages, preferredGroups FROM manga WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		err = ErrMangaNotFound
+	}
+	return
+}
+
+func (m *Manga) UpdateLanguages() error {
+	_, err := DB.Exec("UPDATE manga SET languages = ? WHERE id = ?", m.Languages, m.ID)
+	return err
+}
+
+func (m *Manga) UpdatePreferredGroups() error {
+	_, err := DB.Exec("UPDATE manga SET preferredGroups = ? WHERE id = ?", m.PreferredGroups, m.ID)
+	return err
+}
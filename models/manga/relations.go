@@ -0,0 +1,74 @@
+package manga
+
+import (
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"nonbiri/models/entity"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs1703/logger"
+)
+
+func ByAuthor(id string) (result Slice) {
+	q := `SELECT manga.*,	COUNT(chapter.id) totalChapters, COUNT(history.id) readedChapters, MAX(chapter.publishAt) latestChapterAt FROM manga
+				LEFT JOIN chapter ON chapter.mangaId = manga.id
+				LEFT JOIN history ON history.chapterId = chapter.id AND history.readed = true
+				WHERE manga.id IN (SELECT mangaId FROM manga_author WHERE authorId = ?)
+				GROUP BY manga.id ORDER BY manga.title COLLATE NOCASE`
+
+	if err := DB.Select(&result, q, id); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+func ByTag(id string) (result Slice) {
+	q := `SELECT manga.*,	COUNT(chapter.id) totalChapters, COUNT(history.id) readedChapters, MAX(chapter.publishAt) latestChapterAt FROM manga
+				LEFT JOIN chapter ON chapter.mangaId = manga.id
+				LEFT JOIN history ON history.chapterId = chapter.id AND history.readed = true
+				WHERE manga.id IN (SELECT mangaId FROM manga_tag WHERE tagId = ?)
+				GROUP BY manga.id ORDER BY manga.title COLLATE NOCASE`
+
+	if err := DB.Select(&result, q, id); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// updateRelations replaces the author and tag relations of the manga
+func (m *Manga) updateRelations(tx *sqlx.Tx) error {
+	exec := Exec(tx)
+
+	if _, err := exec("DELETE FROM manga_author WHERE mangaId = ?", m.ID); err != nil {
+		return err
+	}
+
+	for role, authors := range map[Entity]entity.Slice{Entities.Author: m.Authors, Entities.Artist: m.Artists} {
+		for _, a := range authors {
+			q := `INSERT INTO author (id, name) VALUES (?, ?)
+						ON CONFLICT (id) DO UPDATE SET name = excluded.name`
+
+			if _, err := exec(q, a.ID, a.Name); err != nil {
+				return err
+			}
+
+			q = "INSERT OR IGNORE INTO manga_author (mangaId, authorId, role) VALUES (?, ?, ?)"
+			if _, err := exec(q, m.ID, a.ID, role); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := exec("DELETE FROM manga_tag WHERE mangaId = ?", m.ID); err != nil {
+		return err
+	}
+
+	for _, name := range m.Tags {
+		q := "INSERT OR IGNORE INTO manga_tag (mangaId, tagId) SELECT ?, id FROM tag WHERE name = ?"
+		if _, err := exec(q, m.ID, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
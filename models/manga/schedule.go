@@ -0,0 +1,211 @@
+package manga
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Bounds of the interval chosen from the release cadence
+const (
+	maxInterval      = 7 * 24 * time.Hour
+	inactiveInterval = 14 * 24 * time.Hour
+)
+
+// Number of latest chapters used to estimate the release cadence
+const cadenceSamples = 10
+
+// Interval returns how long to wait before the chapters of the manga are checked again,
+// base is the interval used when the release cadence is unknown.
+// 0 means the manga is never updated automatically.
+func (m *Manga) Interval(base time.Duration) (time.Duration, error) {
+	switch m.UpdateMode {
+	case UpdateModes.Never:
+		return 0, nil
+	case UpdateModes.Daily:
+		return 24 * time.Hour, nil
+	case UpdateModes.Weekly:
+		return 7 * 24 * time.Hour, nil
+	}
+
+	switch {
+	case m.FollowState == FollowStates.Dropped,
+		m.Status == Statuses.Completed,
+		m.Status == Statuses.Cancelled:
+		return inactiveInterval, nil
+	case m.FollowState == FollowStates.Completed,
+		m.Status == Statuses.Hiatus:
+		return maxInterval, nil
+	}
+
+	var publishes []int64
+	q := "SELECT DISTINCT publishAt FROM chapter WHERE mangaId = ? AND publishAt > 0 ORDER BY publishAt DESC LIMIT ?"
+	if err := DB.Select(&publishes, q, m.ID, cadenceSamples); err != nil {
+		return 0, err
+	}
+
+	if len(publishes) < 3 {
+		return base, nil
+	}
+
+	var gaps []int64
+	for i := 1; i < len(publishes); i++ {
+		gaps = append(gaps, publishes[i-1]-publishes[i])
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+
+	cadence := time.Duration(gaps[len(gaps)/2]) * time.Second
+	interval := cadence / 4
+
+	// Slows down once a release is long overdue
+	if since := time.Since(time.Unix(publishes[0], 0)); since > 2*cadence {
+		interval = since / 4
+	}
+
+	if interval < base {
+		interval = base
+	} else if interval > maxInterval {
+		interval = maxInterval
+	}
+	return interval, nil
+}
+
+// Reschedule computes the next check of the specified manga from their last check,
+// the ones which are failing keep the next check chosen by Backoff.
+func Reschedule(base time.Duration, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	q, args, err := sqlx.In("SELECT * FROM manga WHERE id IN (?)", ids)
+	if err != nil {
+		return err
+	}
+
+	var entries Slice
+	if err := DB.Select(&entries, DB.Rebind(q), args...); err != nil {
+		return err
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range entries {
+		if m.CheckFailures > 0 || m.Unavailable {
+			continue
+		}
+
+		interval, err := m.Interval(base)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		switch {
+		case interval == 0:
+			m.NextCheck = 0
+		case m.LastChecked == 0:
+			// Has never been checked, is due right away
+			m.NextCheck = time.Now().Unix()
+		default:
+			m.NextCheck = time.Unix(m.LastChecked, 0).Add(interval).Unix()
+		}
+
+		if _, err := m.UpdateSchedule(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Backoff pushes back the next check of the specified manga after they failed to be checked,
+// base is doubled with every consecutive failure up to maxInterval.
+// Manga which are not found are no longer checked automatically.
+func Backoff(base time.Duration, notFound bool, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	q, args, err := sqlx.In("SELECT id, checkFailures FROM manga WHERE id IN (?)", ids)
+	if err != nil {
+		return err
+	}
+
+	var entries Slice
+	if err := DB.Select(&entries, DB.Rebind(q), args...); err != nil {
+		return err
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, m := range entries {
+		m.CheckFailures++
+
+		interval := base
+		for i := 1; i < m.CheckFailures && interval < maxInterval; i++ {
+			interval *= 2
+		}
+		if interval <= 0 || interval > maxInterval {
+			interval = maxInterval
+		}
+
+		q := "UPDATE manga SET checkFailures = ?, nextCheck = ?, unavailable = ? WHERE id = ?"
+		if _, err := tx.Exec(q, m.CheckFailures, now.Add(interval).Unix(), notFound, m.ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Due returns the followed manga whose next check has passed,
+// manga with the excluded follow states and unavailable ones are left out.
+func Due(now time.Time, excluded []FollowState) (result Slice, err error) {
+	q := `SELECT id, title, lastChecked, languages FROM manga
+				WHERE followed = true AND updateMode != ? AND nextCheck <= ? AND unavailable = false`
+	args := []any{UpdateModes.Never, now.Unix()}
+
+	if len(excluded) > 0 {
+		q += " AND followState NOT IN (?)"
+		args = append(args, excluded)
+	}
+	err = selectIn(&result, false, q, args...)
+	return
+}
+
+// Scheduled returns the followed manga which are updated automatically,
+// only the ones with the specified follow states unless it is empty.
+// Unavailable manga are left out.
+func Scheduled(states []FollowState) (result Slice, err error) {
+	q := `SELECT id, title, lastChecked, languages FROM manga
+				WHERE followed = true AND updateMode != ? AND unavailable = false`
+	args := []any{UpdateModes.Never}
+
+	if len(states) > 0 {
+		q += " AND followState IN (?)"
+		args = append(args, states)
+	}
+	err = selectIn(&result, false, q, args...)
+	return
+}
+
+func (m *Manga) UpdateSchedule(tx *sqlx.Tx) (sql.Result, error) {
+	q := `UPDATE 	manga
+				SET			updateMode 	= :updateMode,
+								nextCheck 	= :nextCheck
+				WHERE 	id 					= :id`
+
+	return NamedExec(tx)(q, m)
+}
@@ -0,0 +1,171 @@
+package manga
+
+import (
+	"strings"
+
+	. "nonbiri/database"
+
+	"nonbiri/models/entity"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Match struct {
+	Manga
+
+	Rank       float64    `json:"rank"`
+	Highlights Highlights `json:"highlights" db:"highlights"`
+}
+
+// Highlights wraps matching terms within <mark></mark>
+type Highlights struct {
+	Title       string `json:"title,omitempty"`
+	AltTitles   string `json:"altTitles,omitempty" db:"altTitles"`
+	Description string `json:"description,omitempty"`
+	Authors     string `json:"authors,omitempty"`
+	Artists     string `json:"artists,omitempty"`
+	Tags        string `json:"tags,omitempty"`
+}
+
+type Matches []*Match
+
+// Search performs full-text search over the local manga,
+// the result is ordered by relevance.
+func Search(text string, followed bool, limit, offset int) (result Matches, err error) {
+	if !FTS {
+		return searchLike(text, followed, limit, offset)
+	}
+
+	match := matchExpr(text)
+	if len(match) == 0 {
+		return
+	}
+
+	q := `SELECT
+					manga.*,
+					bm25(manga_fts, 0, 10, 5, 1, 3, 3, 2) rank,
+					highlight(manga_fts, 1, '<mark>', '</mark>') "highlights.title",
+					highlight(manga_fts, 2, '<mark>', '</mark>') "highlights.altTitles",
+					snippet(manga_fts, 3, '<mark>', '</mark>', '…', 24) "highlights.description",
+					highlight(manga_fts, 4, '<mark>', '</mark>') "highlights.authors",
+					highlight(manga_fts, 5, '<mark>', '</mark>') "highlights.artists",
+					highlight(manga_fts, 6, '<mark>', '</mark>') "highlights.tags"
+				FROM manga_fts
+				JOIN manga ON manga.id = manga_fts.id
+				WHERE manga_fts MATCH ?`
+	if followed {
+		q += " AND manga.followed = true"
+	}
+	q += " ORDER BY rank LIMIT ? OFFSET ?"
+
+	err = DB.Select(&result, q, match, limit, offset)
+	return
+}
+
+func searchLike(text string, followed bool, limit, offset int) (result Matches, err error) {
+	terms := strings.Fields(text)
+	if len(terms) == 0 {
+		return
+	}
+
+	var where []string
+	var args []any
+
+	for _, term := range terms {
+		where = append(where, `(title LIKE ? OR altTitles LIKE ? OR description LIKE ?
+			OR authors LIKE ? OR artists LIKE ? OR tags LIKE ?)`)
+		like := "%" + term + "%"
+		args = append(args, like, like, like, like, like, like)
+	}
+
+	if followed {
+		where = append(where, "manga.followed = true")
+	}
+
+	q := "SELECT manga.* FROM manga WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY title COLLATE NOCASE LIMIT ? OFFSET ?"
+
+	err = DB.Select(&result, q, append(args, limit, offset)...)
+	return
+}
+
+// matchExpr quotes every term of the text as a prefix query,
+// which keeps user input from being parsed as FTS5 syntax.
+func matchExpr(text string) string {
+	var terms []string
+	for _, term := range strings.Fields(text) {
+		terms = append(terms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+func (m *Manga) index(tx *sqlx.Tx) error {
+	if !FTS {
+		return nil
+	}
+
+	if _, err := Exec(tx)("DELETE FROM manga_fts WHERE id = ?", m.ID); err != nil {
+		return err
+	}
+
+	q := `INSERT INTO manga_fts (id, title, altTitles, description, authors, artists, tags)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := Exec(tx)(q, m.ID, m.Title,
+		strings.Join(m.AltTitles, "\n"),
+		m.Description,
+		joinNames(m.Authors),
+		joinNames(m.Artists),
+		strings.Join(m.Tags, ", "),
+	)
+	return err
+}
+
+// Reindex rebuilds the full-text index when it is out of sync with the manga table
+func Reindex() error {
+	if !FTS {
+		return nil
+	}
+
+	var indexed, total int
+	if err := DB.Get(&indexed, "SELECT COUNT(*) FROM manga_fts"); err != nil {
+		return err
+	}
+	if err := DB.Get(&total, "SELECT COUNT(*) FROM manga"); err != nil {
+		return err
+	}
+	if indexed == total {
+		return nil
+	}
+
+	var entries Slice
+	if err := DB.Select(&entries, "SELECT * FROM manga"); err != nil {
+		return err
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM manga_fts"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, m := range entries {
+		if err := m.index(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func joinNames(s entity.Slice) string {
+	var names []string
+	for _, e := range s {
+		names = append(names, e.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,17 @@
+package manga
+
+import (
+	"database/sql/driver"
+
+	"nonbiri/utils"
+)
+
+type Titles []string
+
+func (t Titles) Value() (driver.Value, error) {
+	return utils.SliceToBytes(t)
+}
+
+func (t *Titles) Scan(src any) error {
+	return utils.Unmarshal(src, t)
+}
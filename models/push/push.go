@@ -0,0 +1,83 @@
+package push
+
+import (
+	"database/sql/driver"
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+	"nonbiri/utils"
+
+	"github.com/rs1703/logger"
+)
+
+// Subscription is a browser which receives notifications of new chapters
+type Subscription struct {
+	ID       int64  `json:"id"`
+	Endpoint string `json:"endpoint"`
+	P256dh   string `json:"p256dh"`
+	Auth     string `json:"auth"`
+
+	// Only notify the chapters of these manga or of manga with these follow states,
+	// every followed manga is notified when both are empty.
+	MangaIds     Ids    `json:"mangaIds" db:"mangaIds"`
+	FollowStates States `json:"followStates" db:"followStates"`
+
+	CreatedAt int64 `json:"createdAt" db:"createdAt"`
+}
+
+type Slice []*Subscription
+type Ids []string
+type States []FollowState
+
+func (v Ids) Value() (driver.Value, error) {
+	return utils.SliceToBytes(v)
+}
+
+func (v *Ids) Scan(src any) error {
+	return utils.Unmarshal(src, v)
+}
+
+func (v States) Value() (driver.Value, error) {
+	return utils.SliceToBytes(v)
+}
+
+func (v *States) Scan(src any) error {
+	return utils.Unmarshal(src, v)
+}
+
+// Matches reports whether the chapters of the manga are notified to the subscription
+func (s *Subscription) Matches(mangaId string, state FollowState) bool {
+	if len(s.MangaIds) == 0 && len(s.FollowStates) == 0 {
+		return true
+	}
+	return utils.Contains(s.MangaIds, mangaId) || utils.Contains(s.FollowStates, state)
+}
+
+func All() (result Slice) {
+	result = Slice{}
+	if err := DB.Select(&result, "SELECT * FROM push_subscription ORDER BY id"); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// Save stores the subscription, the filters are replaced when the endpoint is already subscribed
+func (s *Subscription) Save() error {
+	s.CreatedAt = time.Now().Unix()
+	q := `INSERT INTO push_subscription (endpoint, p256dh, auth, mangaIds, followStates, createdAt)
+				VALUES (:endpoint, :p256dh, :auth, :mangaIds, :followStates, :createdAt)
+				ON CONFLICT (endpoint) DO UPDATE SET
+					p256dh = excluded.p256dh, auth = excluded.auth,
+					mangaIds = excluded.mangaIds, followStates = excluded.followStates`
+
+	if _, err := NamedExec(nil)(q, s); err != nil {
+		return err
+	}
+	return DB.Get(s, "SELECT * FROM push_subscription WHERE endpoint = ?", s.Endpoint)
+}
+
+func Delete(endpoint string) error {
+	_, err := DB.Exec("DELETE FROM push_subscription WHERE endpoint = ?", endpoint)
+	return err
+}
@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"github.com/rs1703/logger"
+)
+
+// Entry is a task which has been postponed until MangaDex is reachable
+type Entry struct {
+	ID        uint64 `json:"id"`
+	Task      Task   `json:"task"`
+	Body      string `json:"body,omitempty"`
+	CreatedAt int64  `json:"createdAt" db:"createdAt"`
+}
+
+type Slice []*Entry
+
+func All() (result Slice) {
+	if err := DB.Select(&result, "SELECT * FROM queue ORDER BY id"); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+func Count() (result int) {
+	if err := DB.Get(&result, "SELECT COUNT(*) FROM queue"); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// Push adds the task to the queue, the same task with the same body is queued once
+func Push(task Task, body string) error {
+	q := `INSERT OR IGNORE INTO queue (task, body, createdAt)
+				VALUES (?, ?, ?)`
+
+	_, err := DB.Exec(q, task, body, time.Now().Unix())
+	return err
+}
+
+func (e *Entry) Delete() error {
+	_, err := DB.Exec("DELETE FROM queue WHERE id = ?", e.ID)
+	return err
+}
@@ -2,17 +2,22 @@ package tag
 
 import (
 	"database/sql"
+	"fmt"
 
 	. "nonbiri/constants"
 	. "nonbiri/database"
 
-	"nonbiri/models/entity"
-
 	"github.com/rs1703/logger"
 )
 
-type Tag entity.Entity
-type Slice entity.Slice
+type Tag struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Group       string `json:"group,omitempty" db:"tagGroup"`
+	Description string `json:"description,omitempty"`
+}
+
+type Slice []*Tag
 
 func All() (result Slice) {
 	if err := DB.Select(&result, "SELECT * FROM tag"); err != nil {
@@ -23,12 +28,37 @@ func All() (result Slice) {
 
 func One(name string) (result *Tag, err error) {
 	result = &Tag{}
-	if err = DB.Get(result, "SELECT * FROM tag WHERE name = ?", name); err == sql.ErrNoRows {
+	if err = DB.Get(result, "SELECT * FROM tag WHERE name = ? COLLATE NOCASE", name); err == sql.ErrNoRows {
+		err = ErrTagNotFound
+	}
+	return
+}
+
+func ByID(id string) (result *Tag, err error) {
+	result = &Tag{}
+	if err = DB.Get(result, "SELECT * FROM tag WHERE id = ?", id); err == sql.ErrNoRows {
 		err = ErrTagNotFound
 	}
 	return
 }
 
+// Resolve looks up a tag by its id, then by its name
+func Resolve(v string) (*Tag, error) {
+	result, err := ByID(v)
+	if err == ErrTagNotFound {
+		result, err = One(v)
+	}
+	if err == ErrTagNotFound {
+		err = fmt.Errorf("%w: %s", ErrTagNotFound, v)
+	}
+	return result, err
+}
+
 func (t *Tag) Save() (sql.Result, error) {
-	return DB.NamedExec("INSERT OR REPLACE INTO tag VALUES (:id, :name)", t)
+	q := `INSERT INTO tag (id, name, tagGroup, description)
+				VALUES (:id, :name, :tagGroup, :description)
+				ON CONFLICT (id) DO UPDATE
+				SET	name = excluded.name, tagGroup = excluded.tagGroup, description = excluded.description`
+
+	return DB.NamedExec(q, t)
 }
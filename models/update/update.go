@@ -0,0 +1,202 @@
+package update
+
+import (
+	"database/sql"
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs1703/logger"
+)
+
+// Run is a library update which has been started
+type Run struct {
+	ID          int64      `json:"id"`
+	StartedAt   int64      `json:"startedAt" db:"startedAt"`
+	EndedAt     int64      `json:"endedAt" db:"endedAt"`
+	Scheduled   bool       `json:"scheduled"`
+	Kind        UpdateKind `json:"kind"`
+	Cancelled   bool       `json:"cancelled"`
+	Total       int        `json:"total"`
+	NewChapters int        `json:"newChapters" db:"newChapters"`
+	Failed      int        `json:"failed"`
+
+	Entries Entries `json:"entries,omitempty"`
+}
+
+// Entry is the result of a manga within a run
+type Entry struct {
+	RunID       int64        `json:"runId" db:"runId"`
+	MangaID     string       `json:"mangaId" db:"mangaId"`
+	Title       string       `json:"title"`
+	Status      UpdateStatus `json:"status"`
+	NewChapters int          `json:"newChapters" db:"newChapters"`
+	Attempts    int          `json:"attempts"`
+	Error       string       `json:"error,omitempty"`
+	Failure     Failure      `json:"failure"`
+}
+
+// Failing is a followed manga which has failed since it was last updated
+type Failing struct {
+	MangaID      string  `json:"mangaId" db:"mangaId"`
+	Title        string  `json:"title"`
+	Error        string  `json:"error"`
+	Failure      Failure `json:"failure"`
+	Failures     int     `json:"failures"`
+	LastFailedAt int64   `json:"lastFailedAt" db:"lastFailedAt"`
+}
+
+type Runs []*Run
+type Entries []*Entry
+
+func All(limit int) (result Runs) {
+	result = Runs{}
+	if err := DB.Select(&result, "SELECT * FROM update_run ORDER BY id DESC LIMIT ?", limit); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+func One(id int64) (result *Run, err error) {
+	result = &Run{}
+	if err = DB.Get(result, "SELECT * FROM update_run WHERE id = ?", id); err == sql.ErrNoRows {
+		return nil, ErrRunNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	q := "SELECT * FROM update_entry WHERE runId = ? ORDER BY status DESC, title COLLATE NOCASE"
+	err = DB.Select(&result.Entries, q, id)
+	return
+}
+
+// Start stores the run, the ID is assigned to the run
+func Start(r *Run) error {
+	q := `INSERT INTO update_run (startedAt, scheduled, kind, total)
+				VALUES (?, ?, ?, ?)`
+
+	res, err := DB.Exec(q, r.StartedAt, r.Scheduled, r.Kind, r.Total)
+	if err != nil {
+		return err
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+func (r *Run) Finish() (sql.Result, error) {
+	q := `UPDATE 	update_run
+				SET 		endedAt 		= :endedAt, 		cancelled 	= :cancelled,
+								total 			= :total, 			newChapters = :newChapters,
+								failed 			= :failed
+				WHERE 	id 					= :id`
+
+	return NamedExec(nil)(q, r)
+}
+
+func (e *Entry) Save(tx *sqlx.Tx) (sql.Result, error) {
+	q := `INSERT OR REPLACE INTO update_entry (runId, mangaId, title, status, newChapters, attempts, error, failure)
+				VALUES (:runId, :mangaId, :title, :status, :newChapters, :attempts, :error, :failure)`
+
+	return NamedExec(tx)(q, e)
+}
+
+// Failings returns the followed manga which have failed in every run since they were last updated
+func Failings() (result []*Failing) {
+	result = []*Failing{}
+	q := `SELECT
+					e.mangaId,
+					e.title,
+					e.error,
+					e.failure,
+					COUNT(*) failures,
+					MAX(r.startedAt) lastFailedAt
+				FROM update_entry e
+				JOIN update_run r ON r.id = e.runId
+				JOIN manga ON manga.id = e.mangaId AND manga.followed = true
+				WHERE e.status = ? AND e.runId > COALESCE((
+					SELECT MAX(s.runId) FROM update_entry s WHERE s.mangaId = e.mangaId AND s.status = ?
+				), 0)
+				GROUP BY e.mangaId
+				ORDER BY failures DESC, lastFailedAt DESC`
+
+	if err := DB.Select(&result, q, UpdateStatuses.Failed, UpdateStatuses.Updated); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// LastRun returns the last time the schedule fired, 0 if it never did
+func LastRun(key string) (result int64, err error) {
+	err = DB.Get(&result, "SELECT lastRun FROM update_schedule WHERE id = ?", key)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return
+}
+
+func SetLastRun(key string, at int64) error {
+	q := `INSERT INTO update_schedule (id, lastRun) VALUES (?, ?)
+				ON CONFLICT (id) DO UPDATE SET lastRun = excluded.lastRun`
+
+	_, err := DB.Exec(q, key, at)
+	return err
+}
+
+// AddChapters records the chapters discovered by the run
+func AddChapters(runID int64, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	for _, id := range ids {
+		q := "INSERT OR IGNORE INTO update_chapter (runId, chapterId, createdAt) VALUES (?, ?, ?)"
+		if _, err := tx.Exec(q, runID, id, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// PruneChapters deletes the discovered chapters the digest has read, those discovered before sent,
+// and those discovered before expiry whether or not a digest was sent
+func PruneChapters(sent, expiry int64) error {
+	_, err := DB.Exec("DELETE FROM update_chapter WHERE createdAt < ? OR createdAt < ?", sent, expiry)
+	return err
+}
+
+// Prune deletes the runs beyond the latest keep runs,
+// the discovered chapters are kept until PruneChapters
+func Prune(keep int) error {
+	q := "SELECT id FROM update_run ORDER BY id DESC LIMIT 1 OFFSET ?"
+
+	var last int64
+	if err := DB.Get(&last, q, keep-1); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM update_entry WHERE runId < ?", last); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM update_run WHERE id < ?", last); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
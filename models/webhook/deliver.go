@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	. "nonbiri/constants"
+)
+
+// Number of attempts before a delivery is marked as failed
+var MaxAttempts = 5
+
+// Delay before the second attempt, doubled after every attempt
+var Backoff = 5 * time.Second
+
+var Client = &http.Client{Timeout: 15 * time.Second}
+
+const userAgent = "Nonbiri-Webhook"
+
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	},
+	"unix": func(v int64) time.Time {
+		return time.Unix(v, 0)
+	},
+}
+
+func (w *Webhook) parseTemplate() (*template.Template, error) {
+	if len(w.Template) == 0 {
+		return nil, nil
+	}
+	return template.New("webhook").Funcs(funcs).Parse(w.Template)
+}
+
+// Render returns the body posted for the payload
+func (w *Webhook) Render(payload any) ([]byte, error) {
+	tmpl, err := w.parseTemplate()
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		return json.Marshal(payload)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver posts the delivery until it succeeds or MaxAttempts is reached,
+// server errors, rate limits and network errors are retried.
+func (w *Webhook) Deliver(ctx context.Context, d *Delivery) error {
+	delay := Backoff
+	for {
+		retry, err := w.post(ctx, d)
+		d.Attempts++
+
+		if err == nil {
+			d.Status = DeliveryStatuses.Delivered
+			d.Error = ""
+			d.DeliveredAt = time.Now().Unix()
+			return nil
+		}
+		d.Error = err.Error()
+
+		if !retry || d.Attempts >= MaxAttempts {
+			d.Status = DeliveryStatuses.Failed
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post sends a single attempt, reports whether a failed attempt may be retried
+func (w *Webhook) post(ctx context.Context, d *Delivery) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewBufferString(d.Body))
+	if err != nil {
+		return false, err
+	}
+
+	contentType := w.ContentType
+	if len(contentType) == 0 {
+		contentType = "application/json"
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Nonbiri-Event", d.Event.String())
+	req.Header.Set("X-Nonbiri-Delivery", strconv.FormatInt(d.ID, 10))
+	if len(w.Secret) > 0 {
+		req.Header.Set("X-Nonbiri-Signature", "sha256="+Sign(w.Secret, []byte(d.Body)))
+	}
+
+	res, err := Client.Do(req)
+	if err != nil {
+		d.StatusCode = 0
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	d.StatusCode = res.StatusCode
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry = res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout
+	return retry, fmt.Errorf("unexpected status %s", res.Status)
+}
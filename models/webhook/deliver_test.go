@@ -0,0 +1,110 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "nonbiri/constants"
+
+	"nonbiri/models/webhook"
+)
+
+type payload struct {
+	Event string `json:"event"`
+	Title string `json:"title"`
+}
+
+func TestDeliver(t *testing.T) {
+	webhook.Backoff = time.Millisecond
+
+	var calls int32
+	var body, signature, event string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		buf, _ := io.ReadAll(r.Body)
+		body = string(buf)
+		signature = r.Header.Get("X-Nonbiri-Signature")
+		event = r.Header.Get("X-Nonbiri-Event")
+	}))
+	defer receiver.Close()
+
+	w := &webhook.Webhook{
+		URL:      receiver.URL,
+		Template: `{"content": {{json (printf "%s: %s" .Event .Title)}}}`,
+		Secret:   "secret",
+	}
+	if err := w.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := w.Render(payload{Event: "chapter.added", Title: `"Quoted"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &webhook.Delivery{Event: Events.ChapterAdded, Body: string(buf)}
+	if err := w.Deliver(context.Background(), d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Attempts != 3 || d.Status != DeliveryStatuses.Delivered || d.StatusCode != http.StatusOK {
+		t.Errorf("got %d attempts, status %d, code %d", d.Attempts, d.Status, d.StatusCode)
+	}
+	if want := `{"content": "chapter.added: \"Quoted\""}`; body != want {
+		t.Errorf("got body %s, want %s", body, want)
+	}
+	if want := "sha256=" + webhook.Sign("secret", []byte(body)); signature != want {
+		t.Errorf("got signature %s, want %s", signature, want)
+	}
+	if event != "chapter.added" {
+		t.Errorf("got event %s", event)
+	}
+}
+
+func TestDeliverFailure(t *testing.T) {
+	webhook.Backoff = time.Millisecond
+
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("X-Nonbiri-Signature") != "" {
+			t.Error("unsigned webhook sent a signature")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer receiver.Close()
+
+	w := &webhook.Webhook{URL: receiver.URL}
+	buf, err := w.Render(payload{Event: "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &webhook.Delivery{Body: string(buf)}
+	if err := w.Deliver(context.Background(), d); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 || d.Status != DeliveryStatuses.Failed || d.StatusCode != http.StatusNotFound {
+		t.Errorf("got %d calls, status %d, code %d", calls, d.Status, d.StatusCode)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, w := range []*webhook.Webhook{
+		{URL: "ftp://localhost"},
+		{URL: "localhost:8080"},
+		{URL: "http://localhost", Template: "{{.Title"},
+	} {
+		if err := w.Validate(); err == nil {
+			t.Errorf("%+v should be invalid", w)
+		}
+	}
+}
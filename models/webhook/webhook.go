@@ -0,0 +1,232 @@
+package webhook
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+	"nonbiri/utils"
+
+	"github.com/rs1703/logger"
+)
+
+// Webhook posts the events it subscribes to to an URL
+type Webhook struct {
+	ID  int64  `json:"id"`
+	URL string `json:"url"`
+
+	// Subscribed events, empty subscribes to every event
+	Events EventFilter `json:"events"`
+	// Go template executed with the payload, the payload is sent as JSON when empty
+	Template    string `json:"template,omitempty"`
+	ContentType string `json:"contentType,omitempty" db:"contentType"`
+	// Key of the HMAC-SHA256 signature of the body, nothing is signed when empty.
+	// It is never sent back to the client, HasSecret tells whether there is one.
+	Secret    string `json:"-"`
+	HasSecret bool   `json:"hasSecret" db:"-"`
+
+	Enabled   bool  `json:"enabled"`
+	CreatedAt int64 `json:"createdAt" db:"createdAt"`
+}
+
+// Input is a webhook as the client saves it,
+// the stored secret is kept when Secret is nil and removed when it is empty.
+type Input struct {
+	Webhook
+	Secret *string `json:"secret"`
+}
+
+// Delivery is a payload posted to a webhook
+type Delivery struct {
+	ID          int64          `json:"id"`
+	WebhookID   int64          `json:"webhookId" db:"webhookId"`
+	Event       Event          `json:"event"`
+	Body        string         `json:"body"`
+	Status      DeliveryStatus `json:"status"`
+	StatusCode  int            `json:"statusCode" db:"statusCode"`
+	Attempts    int            `json:"attempts"`
+	Error       string         `json:"error,omitempty"`
+	CreatedAt   int64          `json:"createdAt" db:"createdAt"`
+	DeliveredAt int64          `json:"deliveredAt,omitempty" db:"deliveredAt"`
+}
+
+type Slice []*Webhook
+type Deliveries []*Delivery
+type EventFilter []Event
+
+func (e EventFilter) Value() (driver.Value, error) {
+	return utils.SliceToBytes(e)
+}
+
+func (e *EventFilter) Scan(src any) error {
+	return utils.Unmarshal(src, e)
+}
+
+// Has reports whether the event is subscribed, pings are always delivered
+func (e EventFilter) Has(event Event) bool {
+	if len(e) == 0 || event == Events.Ping {
+		return true
+	}
+	for _, v := range e {
+		if v == event {
+			return true
+		}
+	}
+	return false
+}
+
+func All() (result Slice) {
+	result = Slice{}
+	if err := DB.Select(&result, "SELECT * FROM webhook ORDER BY id"); err != nil {
+		logger.Err.Println(err)
+	}
+	for _, w := range result {
+		w.HasSecret = len(w.Secret) > 0
+	}
+	return
+}
+
+func One(id int64) (result *Webhook, err error) {
+	result = &Webhook{}
+	if err = DB.Get(result, "SELECT * FROM webhook WHERE id = ?", id); err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	result.HasSecret = len(result.Secret) > 0
+	return
+}
+
+// Subscribed returns the enabled webhooks which subscribe to the event
+func Subscribed(event Event) (result Slice) {
+	var hooks Slice
+	if err := DB.Select(&hooks, "SELECT * FROM webhook WHERE enabled = true"); err != nil {
+		logger.Err.Println(err)
+		return
+	}
+
+	for _, w := range hooks {
+		if w.Events.Has(event) {
+			result = append(result, w)
+		}
+	}
+	return
+}
+
+// Validate ensures the URL and the template can be used
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return ErrInvalidWebhookURL
+	}
+	_, err = w.parseTemplate()
+	return err
+}
+
+// Save stores the webhook, the ID is assigned to new webhooks
+func (w *Webhook) Save() error {
+	w.HasSecret = len(w.Secret) > 0
+	if w.ID > 0 {
+		q := `UPDATE 	webhook
+					SET 		url 				= :url, 				events 	= :events,
+									template 		= :template, 		contentType = :contentType,
+									secret 			= :secret, 			enabled = :enabled
+					WHERE 	id 					= :id`
+
+		res, err := NamedExec(nil)(q, w)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrWebhookNotFound
+		}
+		return nil
+	}
+
+	w.CreatedAt = time.Now().Unix()
+	q := `INSERT INTO webhook (url, events, template, contentType, secret, enabled, createdAt)
+				VALUES (:url, :events, :template, :contentType, :secret, :enabled, :createdAt)`
+
+	res, err := NamedExec(nil)(q, w)
+	if err != nil {
+		return err
+	}
+	w.ID, err = res.LastInsertId()
+	return err
+}
+
+// Delete removes the webhook and its deliveries
+func Delete(id int64) error {
+	tx, err := DB.Beginx()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM webhook_delivery WHERE webhookId = ?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM webhook WHERE id = ?", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+		return ErrWebhookNotFound
+	}
+	return tx.Commit()
+}
+
+// Log returns the latest deliveries of the webhook
+func Log(id int64, limit int) (result Deliveries) {
+	result = Deliveries{}
+	q := "SELECT * FROM webhook_delivery WHERE webhookId = ? ORDER BY id DESC LIMIT ?"
+	if err := DB.Select(&result, q, id, limit); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// Pending returns the deliveries which were interrupted before they were done
+func Pending() (result Deliveries) {
+	q := "SELECT * FROM webhook_delivery WHERE status = ? ORDER BY id"
+	if err := DB.Select(&result, q, DeliveryStatuses.Pending); err != nil {
+		logger.Err.Println(err)
+	}
+	return
+}
+
+// Save stores the delivery, the ID is assigned to new deliveries
+func (d *Delivery) Save() error {
+	if d.ID > 0 {
+		q := `UPDATE 	webhook_delivery
+					SET 		status 			= :status, 			statusCode 	= :statusCode,
+									attempts 		= :attempts, 		error 			= :error,
+									deliveredAt = :deliveredAt
+					WHERE 	id 					= :id`
+
+		_, err := NamedExec(nil)(q, d)
+		return err
+	}
+
+	q := `INSERT INTO webhook_delivery (webhookId, event, body, status, error, attempts, createdAt)
+				VALUES (:webhookId, :event, :body, :status, :error, :attempts, :createdAt)`
+
+	res, err := NamedExec(nil)(q, d)
+	if err != nil {
+		return err
+	}
+	d.ID, err = res.LastInsertId()
+	return err
+}
+
+// PruneDeliveries deletes the deliveries of the webhook beyond the latest keep deliveries
+func PruneDeliveries(id int64, keep int) error {
+	q := `DELETE FROM webhook_delivery WHERE webhookId = ? AND id < (
+					SELECT id FROM webhook_delivery WHERE webhookId = ? ORDER BY id DESC LIMIT 1 OFFSET ?
+				)`
+
+	_, err := DB.Exec(q, id, id, keep-1)
+	return err
+}
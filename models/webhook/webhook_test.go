@@ -0,0 +1,28 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"nonbiri/models/webhook"
+)
+
+func TestSecretIsNotSent(t *testing.T) {
+	in := &webhook.Input{}
+	if err := json.Unmarshal([]byte(`{"url":"https://example.com","secret":"hunter2"}`), in); err != nil {
+		t.Fatal(err)
+	}
+	if in.Secret == nil || *in.Secret != "hunter2" {
+		t.Fatalf("secret was not read, got %v", in.Secret)
+	}
+
+	w := &webhook.Webhook{URL: "https://example.com", Secret: *in.Secret, HasSecret: true}
+	buf, err := json.Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "hunter2") || !strings.Contains(string(buf), `"hasSecret":true`) {
+		t.Errorf("unexpected body %s", buf)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"nonbiri/services"
+	"nonbiri/utils/opds"
+
+	"github.com/gin-gonic/gin"
+)
+
+type opdsCatalog func(c *gin.Context, q services.OPDSQuery) (*opds.Feed, error)
+
+// registerOPDS serves the catalog as OPDS 1.2 under /opds/v1 and OPDS 2.0 under /opds/v2
+func registerOPDS(router *gin.Engine) {
+	catalogs := map[string]opdsCatalog{
+		"": func(c *gin.Context, q services.OPDSQuery) (*opds.Feed, error) {
+			return services.OPDSCatalog(q)
+		},
+		"/library": func(c *gin.Context, q services.OPDSQuery) (*opds.Feed, error) {
+			return services.OPDSLibrary(q)
+		},
+		"/recent": func(c *gin.Context, q services.OPDSQuery) (*opds.Feed, error) {
+			return services.OPDSRecent(q)
+		},
+		"/downloaded": func(c *gin.Context, q services.OPDSQuery) (*opds.Feed, error) {
+			return services.OPDSDownloaded(q)
+		},
+		"/search": func(c *gin.Context, q services.OPDSQuery) (*opds.Feed, error) {
+			return services.OPDSSearch(q, c.Query("q"))
+		},
+		"/manga/:id": func(c *gin.Context, q services.OPDSQuery) (*opds.Feed, error) {
+			return services.OPDSManga(q, c.Param("id"))
+		},
+	}
+
+	for _, version := range []string{"v1", "v2"} {
+		root := "/opds/" + version
+		for path, catalog := range catalogs {
+			router.GET(root+path, serveOPDS(root, catalog))
+		}
+	}
+
+	router.GET("/opds/v1/opensearch", serveOpenSearch)
+	router.GET("/opds/cbz/:id", serveCBZ)
+}
+
+func serveOPDS(root string, catalog opdsCatalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := services.OPDSQuery{Token: c.Query("token"), BaseURL: baseURL(c), Root: root}
+
+		f, err := catalog(c, q)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		if root == "/opds/v2" {
+			search := q.BaseURL + root + "/search?token=" + url.QueryEscape(q.Token) + "{&q}"
+			buf, err := f.JSON(search)
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+			c.Data(http.StatusOK, opds.ManifestType, buf)
+			return
+		}
+
+		buf, err := f.XML()
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		contentType := opds.NavigationType
+		if len(f.Publications) > 0 {
+			contentType = opds.AcquisitionType
+		}
+		c.Data(http.StatusOK, contentType+";charset=utf-8", buf)
+	}
+}
+
+func serveOpenSearch(c *gin.Context) {
+	token := c.Query("token")
+	template := baseURL(c) + "/opds/v1/search?token=" + url.QueryEscape(token) + "&q={searchTerms}"
+
+	buf, err := opds.OpenSearch("Nonbiri", template)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, opds.SearchType, buf)
+}
+
+// serveCBZ streams the pages of a downloaded chapter as a CBZ archive
+func serveCBZ(c *gin.Context) {
+	cbz, err := services.OpenCBZ(c.Query("token"), c.Param("id"))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", opds.CBZType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(cbz.Name)))
+	c.Status(http.StatusOK)
+	if err := cbz.WriteArchive(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
@@ -7,7 +7,10 @@ import (
 )
 
 type BrowsePreference struct {
-	Language       Language   `json:"language"`
+	// Language of the browsed manga, the first of Languages when they are set
+	Language Language `json:"language"`
+	// Translations of the chapters which are retrieved, ordered by priority
+	Languages      []Language `json:"languages"`
 	Origins        []Language `json:"origins"`
 	ExcludedTags   []string   `json:"excludedTags"`
 	ContentRatings []Rating   `json:"ratings"`
@@ -15,6 +18,7 @@ type BrowsePreference struct {
 
 var Browse = &BrowsePreference{
 	Language:     Languages.English,
+	Languages:    []Language{Languages.English},
 	Origins:      []Language{Languages.Japan},
 	ExcludedTags: []string{"Boys' Love"},
 	ContentRatings: []Rating{
@@ -24,10 +28,23 @@ var Browse = &BrowsePreference{
 	},
 }
 
+// PreferredLanguages returns Languages,
+// configurations written before they existed fall back to Language.
+func (p *BrowsePreference) PreferredLanguages() []Language {
+	if len(p.Languages) > 0 {
+		return p.Languages
+	}
+	return []Language{p.Language}
+}
+
 func (*BrowsePreference) Update(new *BrowsePreference) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
+	if len(new.Languages) > 0 {
+		new.Language = new.Languages[0]
+	}
+
 	*Browse = *new
 	viper.Set("browse", Browse)
 	viper.WriteConfig()
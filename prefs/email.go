@@ -0,0 +1,34 @@
+package prefs
+
+import (
+	"nonbiri/utils/mail"
+
+	"github.com/spf13/viper"
+)
+
+type EmailPreference struct {
+	Enabled bool        `json:"enabled"`
+	SMTP    mail.Server `json:"smtp"`
+	From    string      `json:"from"`
+	To      []string    `json:"to"`
+
+	// Cron expression of when the digest of new chapters is sent
+	Schedule string `json:"schedule"`
+	// Address the links of the digest point to, they are left out when it is empty
+	BaseURL string `json:"baseURL"`
+}
+
+var Email = &EmailPreference{
+	SMTP:     mail.Server{Port: 587},
+	From:     "Nonbiri <nonbiri@localhost>",
+	Schedule: "0 8 * * *",
+}
+
+func (*EmailPreference) Update(new *EmailPreference) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	*Email = *new
+	viper.Set("email", Email)
+	viper.WriteConfig()
+}
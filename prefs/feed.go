@@ -0,0 +1,20 @@
+package prefs
+
+import "github.com/spf13/viper"
+
+type FeedPreference struct {
+	Enabled bool `json:"enabled"`
+	// Required by the feed and OPDS URLs, a new token is generated when it is emptied
+	Token string `json:"token"`
+}
+
+var Feed = &FeedPreference{}
+
+func (*FeedPreference) Update(new *FeedPreference) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	*Feed = *new
+	viper.Set("feed", Feed)
+	viper.WriteConfig()
+}
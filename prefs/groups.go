@@ -0,0 +1,28 @@
+package prefs
+
+import (
+	"nonbiri/models/entity"
+
+	"github.com/spf13/viper"
+)
+
+type GroupPreference struct {
+	// Releases of the preferred groups are chosen first by the collapsed chapter view, in order
+	Preferred entity.Slice `json:"preferred"`
+	// Chapters of the blocked groups never appear in the updates or the notifications
+	Blocked entity.Slice `json:"blocked"`
+}
+
+var Groups = &GroupPreference{
+	Preferred: entity.Slice{},
+	Blocked:   entity.Slice{},
+}
+
+func (*GroupPreference) Update(new *GroupPreference) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	*Groups = *new
+	viper.Set("groups", Groups)
+	viper.WriteConfig()
+}
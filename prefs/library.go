@@ -12,12 +12,27 @@ type LibraryPreference struct {
 	Order           Order         `json:"order"`
 	UpdateFrequency time.Duration `json:"updateFrequency"`
 	LastUpdated     int64         `json:"lastUpdated"`
+
+	// Only request chapters updated since the last check, many manga per request
+	IncrementalUpdates bool `json:"incrementalUpdates"`
+	// Number of manga updated concurrently, requests are still rate limited
+	UpdateWorkers int `json:"updateWorkers"`
+
+	// Chapters of the manga which are not matched by any schedule
+	// are checked according to their release cadence
+	Schedules  []Schedule `json:"schedules"`
+	QuietHours QuietHours `json:"quietHours"`
 }
 
 var Library = &LibraryPreference{
-	Sort:            Sorts.LatestUploadedChapter,
-	Order:           Orders.DESC,
-	UpdateFrequency: 2,
+	Sort:               Sorts.LatestUploadedChapter,
+	Order:              Orders.DESC,
+	UpdateFrequency:    2,
+	IncrementalUpdates: true,
+	UpdateWorkers:      4,
+	Schedules: []Schedule{
+		{Kind: UpdateKinds.Metadata, Times: []string{"04:00"}},
+	},
 }
 
 func (*LibraryPreference) Update(new *LibraryPreference) {
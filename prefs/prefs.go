@@ -12,6 +12,16 @@ import (
 
 var mutex = sync.Mutex{}
 
+// Called after the preferences have been reloaded from the edited config file
+var reloadHooks []func()
+
+// OnReload calls fn every time the preferences are reloaded from the edited config file
+func OnReload(fn func()) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
 func init() {
 	viper.SetConfigName("nonbiri")
 	viper.SetConfigType("json")
@@ -20,6 +30,11 @@ func init() {
 	viper.SetDefault("browse", Browse)
 	viper.SetDefault("library", Library)
 	viper.SetDefault("reader", Reader)
+	viper.SetDefault("network", Network)
+	viper.SetDefault("feed", Feed)
+	viper.SetDefault("push", Push)
+	viper.SetDefault("email", Email)
+	viper.SetDefault("groups", Groups)
 	viper.SetDefault("auth", Auth)
 
 	viper.SafeWriteConfig()
@@ -31,6 +46,11 @@ func init() {
 	utils.Unmarshal(viper.Get("browse"), Browse)
 	utils.Unmarshal(viper.Get("library"), Library)
 	utils.Unmarshal(viper.Get("reader"), Reader)
+	utils.Unmarshal(viper.Get("network"), Network)
+	utils.Unmarshal(viper.Get("feed"), Feed)
+	utils.Unmarshal(viper.Get("push"), Push)
+	utils.Unmarshal(viper.Get("email"), Email)
+	utils.Unmarshal(viper.Get("groups"), Groups)
 	utils.Unmarshal(viper.Get("auth"), Auth)
 	mutex.Unlock()
 
@@ -39,8 +59,18 @@ func init() {
 		utils.Unmarshal(viper.Get("browse"), Browse)
 		utils.Unmarshal(viper.Get("library"), Library)
 		utils.Unmarshal(viper.Get("reader"), Reader)
+		utils.Unmarshal(viper.Get("network"), Network)
+		utils.Unmarshal(viper.Get("feed"), Feed)
+		utils.Unmarshal(viper.Get("push"), Push)
+		utils.Unmarshal(viper.Get("email"), Email)
+		utils.Unmarshal(viper.Get("groups"), Groups)
 		utils.Unmarshal(viper.Get("auth"), Auth)
+		hooks := reloadHooks
 		mutex.Unlock()
+
+		for _, fn := range hooks {
+			fn()
+		}
 	})
 	viper.WatchConfig()
 }
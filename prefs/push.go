@@ -0,0 +1,27 @@
+package prefs
+
+import (
+	"nonbiri/utils/webpush"
+
+	"github.com/spf13/viper"
+)
+
+type PushPreference struct {
+	// Contact sent to the push services, mailto: or https: URL
+	Subject string `json:"subject"`
+	// VAPID keys identifying the server, generated on the first subscription
+	Keys webpush.Keys `json:"keys"`
+}
+
+var Push = &PushPreference{
+	Subject: "mailto:nonbiri@localhost",
+}
+
+func (*PushPreference) Update(new *PushPreference) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	*Push = *new
+	viper.Set("push", Push)
+	viper.WriteConfig()
+}
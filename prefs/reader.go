@@ -22,8 +22,9 @@ type (
 		Zoom            string        `json:"zoom"`
 
 		// Download
-		MaxPreloads int `json:"maxPreloads"`
-		MaxParallel int `json:"maxParallel"`
+		MaxPreloads int         `json:"maxPreloads"`
+		MaxParallel int         `json:"maxParallel"`
+		Quality     PageQuality `json:"quality"`
 
 		// Shortcuts
 		Keybinds Keybinds `json:"keybinds"`
@@ -51,6 +52,7 @@ var Reader = &ReaderPreference{
 
 	MaxPreloads: 3,
 	MaxParallel: 6,
+	Quality:     PageQualities.Original,
 
 	Keybinds: Keybinds{
 		PreviousChapter: "Comma",
@@ -0,0 +1,94 @@
+package prefs
+
+import (
+	"fmt"
+	"time"
+
+	. "nonbiri/constants"
+	"nonbiri/utils/cron"
+)
+
+// Schedule fires an update at every time matching Cron or Times
+type Schedule struct {
+	Kind  UpdateKind `json:"kind"`
+	Cron  string     `json:"cron,omitempty"`
+	Times []string   `json:"times,omitempty"` // Daily times formatted as HH:MM
+
+	// Follow states of the updated manga, empty matches all of them
+	FollowStates []FollowState `json:"followStates,omitempty"`
+}
+
+// QuietHours is a daily range formatted as HH:MM in which nothing is scheduled,
+// the range may span midnight.
+type QuietHours struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+const clockFormat = "15:04"
+
+// Key identifies the schedule across restarts
+func (s *Schedule) Key() string {
+	return fmt.Sprintf("%d|%s|%v|%v", s.Kind, s.Cron, s.Times, s.FollowStates)
+}
+
+// Next returns the first time after t the schedule fires at
+func (s *Schedule) Next(t time.Time) (next time.Time, err error) {
+	if len(s.Cron) > 0 {
+		expr, err := cron.Parse(s.Cron)
+		if err != nil {
+			return next, err
+		}
+		next = expr.Next(t)
+	}
+
+	for _, v := range s.Times {
+		clock, err := time.Parse(clockFormat, v)
+		if err != nil {
+			return next, err
+		}
+
+		at := time.Date(t.Year(), t.Month(), t.Day(), clock.Hour(), clock.Minute(), 0, 0, t.Location())
+		if !at.After(t) {
+			at = at.AddDate(0, 0, 1)
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return
+}
+
+// Matches reports whether manga with the follow state are updated by the schedule
+func (s *Schedule) Matches(state FollowState) bool {
+	if len(s.FollowStates) == 0 {
+		return true
+	}
+	for _, v := range s.FollowStates {
+		if v == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether t is within the quiet hours
+func (q *QuietHours) Contains(t time.Time) bool {
+	start, err := time.Parse(clockFormat, q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(clockFormat, q.End)
+	if err != nil {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
@@ -3,46 +3,40 @@ package anilist
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"nonbiri/utils"
 
-	"github.com/rs1703/logger"
 	"golang.org/x/time/rate"
 )
 
-const baseURL = "https://graphql.anilist.co"
+var baseURL = utils.NewEndpoint("https://graphql.anilist.co")
+
+// SetBaseURL points the requests to another API, such as a mirror or a fake server
+func SetBaseURL(u string) {
+	baseURL.Set(u)
+}
 
 var limiter = rate.NewLimiter(rate.Every(time.Minute/90), 1) // 90 requests/min
 
 // GetBanner retrieves header banner
-func GetBanner(mediaId string) (result string, _ error) {
+func GetBanner(ctx context.Context, mediaId string) (result string, _ error) {
 	id, err := strconv.Atoi(mediaId)
 	if err != nil {
 		return result, err
 	}
-	limiter.Wait(context.Background())
+	start := time.Now()
+	limiter.Wait(ctx)
+	utils.ObserveLimiterWait("anilist", time.Since(start))
 
 	obj := &GraphQl{
 		`query ($id: Int) {Media (id: $id, type: MANGA) {bannerImage}}`,
 		fmt.Sprintf(`{"id": %d}`, id),
 	}
 
-	res, err := http.Post(baseURL, "application/json", obj.Marshal())
-	if err != nil {
-		return result, err
-	}
-	defer func() {
-		if err := res.Body.Close(); err != nil {
-			logger.Err.Println(err)
-		}
-	}()
-
-	buf, err := ioutil.ReadAll(res.Body)
+	buf, err := utils.PostJSON(ctx, baseURL.String(), obj)
 	if err != nil {
 		return result, err
 	}
@@ -0,0 +1,206 @@
+package mangadex
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	. "nonbiri/constants"
+	"nonbiri/utils"
+
+	"github.com/rs1703/logger"
+)
+
+// https://api.mangadex.org/docs/retrieving-chapter/
+// Base URLs of the MangaDex@Home nodes are valid for 15 minutes,
+// they are refreshed a little earlier.
+const nodeTTL = 10 * time.Minute
+
+// Where the nodes are told whether the pages have been retrieved successfully
+var reportURL = utils.NewEndpoint("https://api.mangadex.network/report")
+
+// Requests of the pages and their reports are counted apart from the API
+const pagesUpstream = "mangadex@home"
+
+func init() {
+	utils.SetUpstream(pagesUpstream, reportURL.String())
+}
+
+// SetReportURL sends the reports of the pages somewhere else, such as a mirror or a fake server
+func SetReportURL(u string) {
+	reportURL.Set(u)
+	utils.SetUpstream(pagesUpstream, u)
+}
+
+// Pages are not retried by the client, a failing node is replaced instead
+var pageClient = &http.Client{Timeout: 30 * time.Second, Transport: utils.Client.Transport}
+
+type atHomeNode struct {
+	BaseURL   string
+	ExpiresAt time.Time
+}
+
+var nodes = struct {
+	sync.Mutex
+	byChapter map[string]*atHomeNode
+}{byChapter: make(map[string]*atHomeNode)}
+
+// Report is the outcome of a page retrieval sent to the MangaDex@Home network
+type Report struct {
+	URL      string `json:"url"`
+	Success  bool   `json:"success"`
+	Cached   bool   `json:"cached"`
+	Bytes    int    `json:"bytes"`
+	Duration int64  `json:"duration"` // milliseconds
+}
+
+// rememberNode keeps the base URL returned for the chapter until it expires,
+// the nodes of the other chapters which have expired are forgotten.
+func rememberNode(chapterId string, metadata *ChapterPagesMetadata) {
+	if metadata == nil || len(metadata.BaseURL) == 0 {
+		return
+	}
+
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	now := time.Now()
+	for id, n := range nodes.byChapter {
+		if !now.Before(n.ExpiresAt) {
+			delete(nodes.byChapter, id)
+		}
+	}
+	nodes.byChapter[chapterId] = &atHomeNode{BaseURL: metadata.BaseURL, ExpiresAt: now.Add(nodeTTL)}
+}
+
+// nodeURL returns the base URL of the node serving the chapter,
+// a new one is requested when it has expired or refresh is true.
+func nodeURL(ctx context.Context, chapterId string, refresh bool) (string, error) {
+	nodes.Lock()
+	n, ok := nodes.byChapter[chapterId]
+	if ok && !time.Now().Before(n.ExpiresAt) {
+		delete(nodes.byChapter, chapterId)
+		ok = false
+	}
+	nodes.Unlock()
+
+	if ok && !refresh {
+		return n.BaseURL, nil
+	}
+
+	metadata, err := GetPages(ctx, chapterId)
+	if err != nil {
+		return "", err
+	}
+	if len(metadata.BaseURL) == 0 {
+		return "", ErrNoNode
+	}
+	return metadata.BaseURL, nil
+}
+
+// FetchPage retrieves a page of the chapter from the MangaDex@Home node serving it,
+// quality is either "data" or "data-saver".
+// When the page fails, the node is refreshed to fail over to another one,
+// then the page is retrieved from the uploads server instead.
+// The uploads server is used right away when chapterId is empty.
+func FetchPage(ctx context.Context, chapterId, quality, hash, file string) ([]byte, error) {
+	pathname := "/" + strings.Join([]string{quality, hash, file}, "/")
+
+	var previous string
+	for _, refresh := range []bool{false, true} {
+		if len(chapterId) == 0 {
+			break
+		}
+
+		baseURL, err := nodeURL(ctx, chapterId, refresh)
+		if err != nil {
+			logger.Err.Println(chapterId, err)
+			break
+		}
+		if baseURL == previous || baseURL == ImagesURL() {
+			break
+		}
+		previous = baseURL
+
+		buf, err := fetchNodePage(ctx, baseURL+pathname)
+		if err == nil {
+			return buf, nil
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logger.Err.Println(err)
+	}
+
+	buf, _, err := fetchPage(ctx, ImagesURL()+pathname)
+	return buf, err
+}
+
+// fetchNodePage retrieves the page from a node and reports the outcome
+func fetchNodePage(ctx context.Context, u string) ([]byte, error) {
+	start := time.Now()
+	buf, res, err := fetchPage(ctx, u)
+	// The node is not at fault when the request has been cancelled
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r := &Report{
+		URL:      u,
+		Success:  err == nil,
+		Bytes:    len(buf),
+		Duration: time.Since(start).Milliseconds(),
+	}
+	if res != nil {
+		r.Cached = strings.HasPrefix(res.Header.Get("X-Cache"), "HIT")
+	}
+	go report(r)
+
+	return buf, err
+}
+
+func fetchPage(ctx context.Context, u string) ([]byte, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := pageClient.Do(req)
+	if err != nil {
+		utils.CountUpstream(pagesUpstream, 0)
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	utils.CountUpstream(pagesUpstream, res.StatusCode)
+
+	if res.StatusCode != http.StatusOK {
+		return nil, res, &PageError{URL: u, StatusCode: res.StatusCode}
+	}
+
+	buf, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, res, err
+	}
+	if res.ContentLength >= 0 && int64(len(buf)) != res.ContentLength {
+		return nil, res, io.ErrUnexpectedEOF
+	}
+	return buf, res, nil
+}
+
+func report(r *Report) {
+	if _, err := utils.PostJSON(context.Background(), reportURL.String(), r); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// PageError is returned when a page is responded with another status than 200
+type PageError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *PageError) Error() string {
+	return http.StatusText(e.StatusCode) + ": " + e.URL
+}
@@ -0,0 +1,180 @@
+package mangadex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const testChapterId = "11111111-1111-1111-1111-111111111111"
+
+// fakeNetwork serves the at-home API, the uploads server and the report endpoint,
+// the at-home API hands out next as the node of the chapter.
+func fakeNetwork(t *testing.T, next string) (chan *Report, func()) {
+	reports := make(chan *Report, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/at-home/server/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"result":"ok","baseUrl":%q,"chapter":{"hash":"hash","data":["1.png"]}}`, next)
+	})
+	mux.HandleFunc("/data/hash/1.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("uploads"))
+	})
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		report := &Report{}
+		if err := json.NewDecoder(r.Body).Decode(report); err != nil {
+			t.Error(err)
+		}
+		reports <- report
+	})
+	server := httptest.NewServer(mux)
+
+	previousBaseURL, previousImages, previousReportURL := baseURL.String(), imagesURL.String(), reportURL.String()
+	baseURL.Set(server.URL)
+	imagesURL.Set(server.URL)
+	reportURL.Set(server.URL + "/report")
+	atHomeLimiter = rate.NewLimiter(rate.Inf, 1)
+
+	return reports, func() {
+		baseURL.Set(previousBaseURL)
+		imagesURL.Set(previousImages)
+		reportURL.Set(previousReportURL)
+		nodes.byChapter = make(map[string]*atHomeNode)
+		server.Close()
+	}
+}
+
+// fakeNode serves the page, or fails every request when ok is false
+func fakeNode(ok bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ok || r.URL.Path != "/data/hash/1.png" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Cache", "HIT")
+		w.Write([]byte("node"))
+	}))
+}
+
+func receive(t *testing.T, reports chan *Report) *Report {
+	select {
+	case r := <-reports:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no report has been sent")
+		return nil
+	}
+}
+
+func TestFetchPage(t *testing.T) {
+	node := fakeNode(true)
+	defer node.Close()
+	reports, done := fakeNetwork(t, node.URL)
+	defer done()
+
+	buf, err := FetchPage(context.Background(), testChapterId, "data", "hash", "1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "node" {
+		t.Errorf("got %q, want the page of the node", buf)
+	}
+
+	r := receive(t, reports)
+	if r.URL != node.URL+"/data/hash/1.png" || !r.Success || !r.Cached || r.Bytes != 4 {
+		t.Errorf("unexpected report %+v", r)
+	}
+}
+
+func TestFetchPageFailover(t *testing.T) {
+	failing, node := fakeNode(false), fakeNode(true)
+	defer failing.Close()
+	defer node.Close()
+	reports, done := fakeNetwork(t, node.URL)
+	defer done()
+
+	rememberNode(testChapterId, &ChapterPagesMetadata{BaseURL: failing.URL})
+
+	buf, err := FetchPage(context.Background(), testChapterId, "data", "hash", "1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "node" {
+		t.Errorf("got %q, want the page of the refreshed node", buf)
+	}
+
+	// Reports are sent concurrently
+	success := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		r := receive(t, reports)
+		success[r.URL] = r.Success
+	}
+	if ok, sent := success[failing.URL+"/data/hash/1.png"]; !sent || ok {
+		t.Errorf("the failure has not been reported: %v", success)
+	}
+	if ok := success[node.URL+"/data/hash/1.png"]; !ok {
+		t.Errorf("the success has not been reported: %v", success)
+	}
+}
+
+func TestFetchPageExpired(t *testing.T) {
+	stale, node := fakeNode(true), fakeNode(true)
+	defer stale.Close()
+	defer node.Close()
+	reports, done := fakeNetwork(t, node.URL)
+	defer done()
+
+	nodes.byChapter[testChapterId] = &atHomeNode{BaseURL: stale.URL, ExpiresAt: time.Now().Add(-time.Minute)}
+
+	if _, err := FetchPage(context.Background(), testChapterId, "data", "hash", "1.png"); err != nil {
+		t.Fatal(err)
+	}
+	if r := receive(t, reports); r.URL != node.URL+"/data/hash/1.png" {
+		t.Errorf("the expired node has been used: %+v", r)
+	}
+}
+
+func TestFetchPageUploads(t *testing.T) {
+	failing := fakeNode(false)
+	defer failing.Close()
+	reports, done := fakeNetwork(t, failing.URL)
+	defer done()
+
+	buf, err := FetchPage(context.Background(), testChapterId, "data", "hash", "1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "uploads" {
+		t.Errorf("got %q, want the page of the uploads server", buf)
+	}
+
+	// Only the failing node is reported, the uploads server is not part of the network
+	receive(t, reports)
+	select {
+	case r := <-reports:
+		t.Errorf("unexpected report %+v", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRememberNodeForgetsExpired(t *testing.T) {
+	_, done := fakeNetwork(t, "")
+	defer done()
+
+	nodes.byChapter["expired"] = &atHomeNode{BaseURL: "https://expired", ExpiresAt: time.Now().Add(-time.Minute)}
+	nodes.byChapter["valid"] = &atHomeNode{BaseURL: "https://valid", ExpiresAt: time.Now().Add(time.Minute)}
+
+	rememberNode(testChapterId, &ChapterPagesMetadata{BaseURL: "https://node"})
+
+	if _, ok := nodes.byChapter["expired"]; ok {
+		t.Error("the expired node is still remembered")
+	}
+	if len(nodes.byChapter) != 2 {
+		t.Errorf("got %d nodes, want 2", len(nodes.byChapter))
+	}
+}
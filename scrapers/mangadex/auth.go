@@ -27,7 +27,7 @@ type Token struct {
 
 var loginLimiter = rate.NewLimiter(rate.Every(time.Hour/30), 1) // 30 requests/h
 
-func Login(o Authorization) (*Token, error) {
+func Login(ctx context.Context, o Authorization) (*Token, error) {
 	if len(o.Username) == 0 && len(o.Email) == 0 {
 		return nil, errors.New("username and email can not be empty at the same time")
 	}
@@ -36,10 +36,10 @@ func Login(o Authorization) (*Token, error) {
 		return nil, errors.New(("password can not be empty"))
 	}
 
-	loginLimiter.Wait(context.Background())
+	wait(ctx, loginLimiter)
 
 	url := buildURL("auth/login")
-	buf, err := utils.PostJSON(url, o)
+	buf, err := utils.PostJSON(ctx, url, o)
 	if err != nil {
 		return nil, err
 	}
@@ -57,15 +57,15 @@ func Login(o Authorization) (*Token, error) {
 
 var refreshLimiter = rate.NewLimiter(rate.Every(time.Hour/60), 1) // 60 requests/h
 
-func RefreshToken(refreshToken string) (*Token, error) {
+func RefreshToken(ctx context.Context, refreshToken string) (*Token, error) {
 	if len(refreshToken) == 0 {
 		return nil, errors.New("refreshToken can not be empty")
 	}
 
-	refreshLimiter.Wait(context.Background())
+	wait(ctx, refreshLimiter)
 
 	url := buildURL("auth/refresh")
-	buf, err := utils.PostJSON(url, fmt.Sprintf(`{"token": "%s"}`, refreshToken))
+	buf, err := utils.PostJSON(ctx, url, fmt.Sprintf(`{"token": "%s"}`, refreshToken))
 	if err != nil {
 		return nil, err
 	}
@@ -81,18 +81,18 @@ func RefreshToken(refreshToken string) (*Token, error) {
 	return res.Token, nil
 }
 
-func CheckToken(sessionToken string) (bool, error) {
+func CheckToken(ctx context.Context, sessionToken string) (bool, error) {
 	if len(sessionToken) == 0 {
 		return false, errors.New("sessionToken can not be empty")
 	}
 
-	limiter.Wait(context.Background())
+	wait(ctx, limiter)
 
 	header := map[string]string{}
 	header["Authorization"] = fmt.Sprintf("Bearer %s", sessionToken)
 
 	url := buildURL("auth/check")
-	buf, err := utils.PostJSON(url, header)
+	buf, err := utils.PostJSON(ctx, url, header)
 	if err != nil {
 		return false, err
 	}
@@ -118,12 +118,12 @@ func (o *FollowQuery) buildURL() string {
 	return buildURL("user/follows/manga", query.Parse(o))
 }
 
-func GetFollows(token *Token, q FollowQuery) ([]*Manga, error) {
+func GetFollows(ctx context.Context, token *Token, q FollowQuery) ([]*Manga, error) {
 	if token == nil || len(token.Session) == 0 {
 		return nil, errors.New("session token can not be empty")
 	}
 
-	ok, err := CheckToken(token.Session)
+	ok, err := CheckToken(ctx, token.Session)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +133,7 @@ func GetFollows(token *Token, q FollowQuery) ([]*Manga, error) {
 			return nil, errors.New("session token is expired and failed to retrieve a new session token because refresh token is empty")
 		}
 
-		newToken, err := RefreshToken(token.Refresh)
+		newToken, err := RefreshToken(ctx, token.Refresh)
 		if err != nil {
 			return nil, err
 		}
@@ -143,9 +143,9 @@ func GetFollows(token *Token, q FollowQuery) ([]*Manga, error) {
 	var entries []*Manga
 
 	for {
-		_ = limiter.Wait(context.Background())
+		wait(ctx, limiter)
 
-		buf, err := utils.Get(q.buildURL())
+		buf, err := utils.Get(ctx, q.buildURL())
 		if err != nil {
 			return nil, err
 		}
@@ -165,8 +165,8 @@ func GetFollows(token *Token, q FollowQuery) ([]*Manga, error) {
 	return entries, nil
 }
 
-func GetFollowsEx(token *Token, q FollowQuery) (manga.Slice, error) {
-	data, err := GetFollows(token, q)
+func GetFollowsEx(ctx context.Context, token *Token, q FollowQuery) (manga.Slice, error) {
+	data, err := GetFollows(ctx, token, q)
 	if err != nil {
 		return nil, err
 	}
@@ -23,9 +23,9 @@ import (
 type FeedQuery struct {
 	Limit                    int      `define:"limit,omitempty" min:"1" max:"100" default:"100"`
 	Offset                   int      `define:"offset,omitempty"`
-	TranslatedLanguage       []string `define:"translatedLanguage[]" enum:"en,zh,ja,ko" default:"en"`
-	OriginalLanguage         []string `define:"originalLanguage[]" enum:"en,zh,ja,ko" default:"jp"`
-	ExcludedOriginalLanguage []string `define:"excludedOriginalLanguage[]" enum:"en,zh,ja,ko"`
+	TranslatedLanguage       []string `define:"translatedLanguage[]" default:"en"`
+	OriginalLanguage         []string `define:"originalLanguage[]"`
+	ExcludedOriginalLanguage []string `define:"excludedOriginalLanguage[]"`
 	ContentRating            []string `define:"contentRating[]" enum:"safe,suggestive,erotica,pornographic" default:"safe,suggestive,erotica"`
 	IncludeFutureUpdates     int      `define:"includeFutureUpdates" enum:"0,1" default:"1"`
 
@@ -52,11 +52,12 @@ type ChapterQuery struct {
 	Groups                   []string `define:"groups[]"`
 	Uploader                 string   `define:"title"`
 	Manga                    string   `define:"manga"`
+	Mangas                   []string `define:"manga[]" max:"100"`
 	Volume                   []string `define:"volume[]"`
 	Chapter                  []string `define:"chapter[]"`
-	TranslatedLanguage       []string `define:"translatedLanguage[]" enum:"en,zh,ja,ko" default:"en"`
-	OriginalLanguage         []string `define:"originalLanguage[]" enum:"en,zh,ja,ko" default:"jp"`
-	ExcludedOriginalLanguage []string `define:"excludedOriginalLanguage[]" enum:"en,zh,ja,ko"`
+	TranslatedLanguage       []string `define:"translatedLanguage[]" default:"en"`
+	OriginalLanguage         []string `define:"originalLanguage[]"`
+	ExcludedOriginalLanguage []string `define:"excludedOriginalLanguage[]"`
 	ContentRating            []string `define:"contentRating[]" enum:"safe,suggestive,erotica,pornographic" default:"safe,suggestive,erotica"`
 	IncludeFutureUpdates     int      `define:"includeFutureUpdates" enum:"0,1" default:"1"`
 
@@ -79,17 +80,17 @@ func (o *ChapterQuery) buildURL() string {
 }
 
 // GetChapter retrieves chapter data
-func GetChapter(id string) (*Chapter, error) {
-	if err := validateId(id); err != nil {
+func GetChapter(ctx context.Context, id string) (*Chapter, error) {
+	if err := ValidateId(id); err != nil {
 		return nil, err
 	}
-	_ = limiter.Wait(context.Background())
+	wait(ctx, limiter)
 
 	q := &url.Values{}
 	q.Add("includes[]", "scanlation_group")
 
 	url := buildURL(path.Join("chapter", id), q)
-	buf, err := utils.Get(url)
+	buf, err := utils.Get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -108,20 +109,21 @@ func GetChapter(id string) (*Chapter, error) {
 	}
 
 	if len(res.Data.Attributes.Hash) == 0 || len(res.Data.Attributes.Data) == 0 {
-		pagesMetadata, err := GetPages(id)
+		pagesMetadata, err := GetPages(ctx, id)
 		if err != nil {
 			return nil, err
 		}
 		res.Data.Attributes.Hash = pagesMetadata.Chapter.Hash
 		res.Data.Attributes.Data = pagesMetadata.Chapter.Data
+		res.Data.Attributes.DataSaver = pagesMetadata.Chapter.DataSaver
 	}
 	return res.Data, nil
 }
 
 // GetChapterEx retrieves chapter data
 //  - This function returns normalized data
-func GetChapterEx(id string) (*chapter.Chapter, error) {
-	data, err := GetChapter(id)
+func GetChapterEx(ctx context.Context, id string) (*chapter.Chapter, error) {
+	data, err := GetChapter(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -129,8 +131,8 @@ func GetChapterEx(id string) (*chapter.Chapter, error) {
 }
 
 // GetChapters retrieves all chapters of specified manga
-func GetChapters(mangaId string, q FeedQuery) ([]*Chapter, error) {
-	if err := validateId(mangaId); err != nil {
+func GetChapters(ctx context.Context, mangaId string, q FeedQuery) ([]*Chapter, error) {
+	if err := ValidateId(mangaId); err != nil {
 		return nil, err
 	}
 
@@ -138,10 +140,10 @@ func GetChapters(mangaId string, q FeedQuery) ([]*Chapter, error) {
 	var entries []*Chapter
 
 	for {
-		_ = limiter.Wait(context.Background())
+		wait(ctx, limiter)
 
 		url := buildURL(fmt.Sprintf("manga/%s/feed", mangaId), queries)
-		buf, err := utils.Get(url)
+		buf, err := utils.Get(ctx, url)
 		if err != nil {
 			return nil, err
 		}
@@ -163,8 +165,68 @@ func GetChapters(mangaId string, q FeedQuery) ([]*Chapter, error) {
 
 // GetChaptersEx retrieves all chapters of specified manga
 //  - This function returns normalized data
-func GetChaptersEx(mangaId string, q FeedQuery) (chapter.Slice, error) {
-	data, err := GetChapters(mangaId, q)
+func GetChaptersEx(ctx context.Context, mangaId string, q FeedQuery) (chapter.Slice, error) {
+	data, err := GetChapters(ctx, mangaId, q)
+	if err != nil {
+		return nil, err
+	}
+	var entries chapter.Slice
+	for _, entry := range data {
+		if x := entry.Normalize(); x != nil {
+			entries = append(entries, x)
+		}
+	}
+	return entries, err
+}
+
+// GetChaptersSince retrieves the chapters of up to 100 manga at once
+// which have been updated since the specified time,
+// the chapters of q.Groups are retrieved instead when mangaIds is empty.
+func GetChaptersSince(ctx context.Context, mangaIds []string, since time.Time, q ChapterQuery) ([]*Chapter, error) {
+	if len(mangaIds) > 100 {
+		return nil, ErrTooManyIds
+	}
+
+	q.Limit = 100
+	q.Offset = 0
+	q.Mangas = mangaIds
+	q.UpdatedAtSince = since.UTC().Format(dateFormat)
+	q.Sort = ChapterSort{By: "updatedAt", Order: "asc"}
+
+	var entries []*Chapter
+	for {
+		data, info, err := SearchChapter(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, data...)
+		if len(data) == 0 || info.Offset+info.Limit >= info.Total {
+			break
+		}
+		q.Offset += info.Limit
+
+		// Listings can not be paged beyond maxResultWindow,
+		// continue from the last update instead.
+		if q.Offset+q.Limit > maxResultWindow {
+			last := utils.ParseDateString(data[len(data)-1].Attributes.UpdatedAt).UTC().Format(dateFormat)
+			// The whole window has been updated in the same second, it can not be paged any further
+			if last == q.UpdatedAtSince {
+				return nil, ErrResultWindowExceeded
+			}
+			q.UpdatedAtSince = last
+			q.Offset = 0
+		}
+	}
+
+	return entries, nil
+}
+
+// GetChaptersSinceEx retrieves the chapters of up to 100 manga at once
+// which have been updated since the specified time.
+//  - This function returns normalized data
+func GetChaptersSinceEx(ctx context.Context, mangaIds []string, since time.Time, q ChapterQuery) (chapter.Slice, error) {
+	data, err := GetChaptersSince(ctx, mangaIds, since, q)
 	if err != nil {
 		return nil, err
 	}
@@ -178,15 +240,25 @@ func GetChaptersEx(mangaId string, q FeedQuery) (chapter.Slice, error) {
 }
 
 // SearchChapter searches and retrieves chapter data
-func SearchChapter(q ChapterQuery) ([]*Chapter, *QueryResultInfo, error) {
+func SearchChapter(ctx context.Context, q ChapterQuery) ([]*Chapter, *QueryResultInfo, error) {
 	for _, id := range q.Ids {
-		if err := validateId(id); err != nil {
+		if err := ValidateId(id); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, id := range q.Mangas {
+		if err := ValidateId(id); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, id := range q.Groups {
+		if err := ValidateId(id); err != nil {
 			return nil, nil, err
 		}
 	}
-	_ = limiter.Wait(context.Background())
+	wait(ctx, limiter)
 
-	buf, err := utils.Get(q.buildURL())
+	buf, err := utils.Get(ctx, q.buildURL())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -204,8 +276,8 @@ func SearchChapter(q ChapterQuery) ([]*Chapter, *QueryResultInfo, error) {
 
 // SearchChapterEx searches and retrieves chapter data
 //  - This function returns normalized data
-func SearchChapterEx(q ChapterQuery) ([]*chapter.Chapter, *QueryResultInfo, error) {
-	data, info, err := SearchChapter(q)
+func SearchChapterEx(ctx context.Context, q ChapterQuery) ([]*chapter.Chapter, *QueryResultInfo, error) {
+	data, info, err := SearchChapter(ctx, q)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -220,11 +292,11 @@ func SearchChapterEx(q ChapterQuery) ([]*chapter.Chapter, *QueryResultInfo, erro
 
 var atHomeLimiter = rate.NewLimiter(rate.Every(time.Minute/40), 1) // 40 requests/minute
 
-func GetPages(chapterId string) (*ChapterPagesMetadata, error) {
-	atHomeLimiter.Wait(context.Background())
+func GetPages(ctx context.Context, chapterId string) (*ChapterPagesMetadata, error) {
+	wait(ctx, atHomeLimiter)
 
 	u := path.Join("/at-home/server", chapterId)
-	buf, err := utils.Get(buildURL(u))
+	buf, err := utils.Get(ctx, buildURL(u))
 	if err != nil {
 		return nil, err
 	}
@@ -237,5 +309,6 @@ func GetPages(chapterId string) (*ChapterPagesMetadata, error) {
 	if len(res.Errors) > 0 {
 		return nil, errors.New(res.Errors[0].Detail)
 	}
+	rememberNode(chapterId, res.ChapterPagesMetadata)
 	return res.ChapterPagesMetadata, err
 }
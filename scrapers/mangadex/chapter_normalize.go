@@ -19,25 +19,15 @@ func (self *Chapter) Normalize() *chapter.Chapter {
 	c.Volume = self.Attributes.Volume
 	c.Chapter = self.Attributes.Chapter
 
-	switch self.Attributes.TranslatedLanguage {
-	case Languages.English.String():
-		c.Language = Languages.English
-		break
-	case Languages.Chinese.String():
-		c.Language = Languages.Chinese
-		break
-	case Languages.Japan.String():
-		c.Language = Languages.Japan
-		break
-	case Languages.Korean.String():
-		c.Language = Languages.Korean
-		break
+	if language, ok := ParseLanguage(self.Attributes.TranslatedLanguage); ok {
+		c.Language = language
 	}
 
 	c.ExternalURL = self.Attributes.ExternalURL
 	c.Hash = self.Attributes.Hash
 
 	c.Pages = self.Attributes.Data
+	c.DataSaverPages = self.Attributes.DataSaver
 
 	c.CreatedAt = utils.ParseDateString(self.Attributes.CreatedAt).Unix()
 	c.PublishAt = utils.ParseDateString(self.Attributes.PublishAt).Unix()
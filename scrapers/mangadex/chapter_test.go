@@ -0,0 +1,47 @@
+package mangadex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "nonbiri/constants"
+
+	"golang.org/x/time/rate"
+)
+
+func TestGetChaptersSinceStuckWindow(t *testing.T) {
+	// Every chapter has been updated in the same second
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data := make([]string, 100)
+		for i := range data {
+			data[i] = `{"id":"c","attributes":{"updatedAt":"2022-01-01T00:00:00+00:00"}}`
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		fmt.Fprintf(w, `{"result":"ok","data":[%s],"limit":100,"offset":%d,"total":20000}`, strings.Join(data, ","), offset)
+	}))
+	defer server.Close()
+
+	previousBaseURL, previousLimiter := baseURL.String(), limiter
+	baseURL.Set(server.URL)
+	limiter = rate.NewLimiter(rate.Inf, 1)
+	defer func() {
+		baseURL.Set(previousBaseURL)
+		limiter = previousLimiter
+	}()
+
+	since := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := GetChaptersSince(context.Background(), nil, since, ChapterQuery{}); err != ErrResultWindowExceeded {
+		t.Errorf("got %v, want ErrResultWindowExceeded", err)
+	}
+	if requests > maxResultWindow/100 {
+		t.Errorf("sent %d requests", requests)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"time"
 
 	. "nonbiri/constants"
 	"nonbiri/utils"
@@ -54,12 +55,12 @@ func (o *MangaQuery) buildURL() string {
 }
 
 // GetManga retrieves manga metadata
-func GetManga(id string) (*Manga, error) {
-	if err := validateId(id); err != nil {
+func GetManga(ctx context.Context, id string) (*Manga, error) {
+	if err := ValidateId(id); err != nil {
 		return nil, err
 	}
 
-	_ = limiter.Wait(context.Background())
+	wait(ctx, limiter)
 
 	q := &url.Values{}
 	q.Add("includes[]", "manga")
@@ -68,7 +69,7 @@ func GetManga(id string) (*Manga, error) {
 	q.Add("includes[]", "artist")
 
 	url := buildURL(path.Join("manga", id), q)
-	buf, err := utils.Get(url)
+	buf, err := utils.Get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -90,8 +91,8 @@ func GetManga(id string) (*Manga, error) {
 
 // GetMangaEx retrieves manga metadata
 //  - This function returns normalized data
-func GetMangaEx(id string) (*manga.Manga, error) {
-	data, err := GetManga(id)
+func GetMangaEx(ctx context.Context, id string) (*manga.Manga, error) {
+	data, err := GetManga(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -99,16 +100,16 @@ func GetMangaEx(id string) (*manga.Manga, error) {
 }
 
 // SearchManga searches and retrieves manga metadata
-func SearchManga(q MangaQuery) ([]*Manga, *QueryResultInfo, error) {
+func SearchManga(ctx context.Context, q MangaQuery) ([]*Manga, *QueryResultInfo, error) {
 	for _, id := range q.Ids {
-		if err := validateId(id); err != nil {
+		if err := ValidateId(id); err != nil {
 			return nil, nil, err
 		}
 	}
 
-	_ = limiter.Wait(context.Background())
+	wait(ctx, limiter)
 
-	buf, err := utils.Get(q.buildURL())
+	buf, err := utils.Get(ctx, q.buildURL())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -126,8 +127,8 @@ func SearchManga(q MangaQuery) ([]*Manga, *QueryResultInfo, error) {
 
 // SearchMangaEx searches and retrieves manga metadata
 //  - This function returns normalized data
-func SearchMangaEx(q MangaQuery) (manga.Slice, *QueryResultInfo, error) {
-	data, info, err := SearchManga(q)
+func SearchMangaEx(ctx context.Context, q MangaQuery) (manga.Slice, *QueryResultInfo, error) {
+	data, info, err := SearchManga(ctx, q)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -141,11 +142,60 @@ func SearchMangaEx(q MangaQuery) (manga.Slice, *QueryResultInfo, error) {
 	return entries, info, err
 }
 
+// GetMangaSince retrieves the manga of up to 100 authors at once
+// which have been created since the specified time, as authors or as artists.
+//  - This function returns normalized data
+func GetMangaSince(ctx context.Context, authorIds []string, since time.Time) (manga.Slice, error) {
+	if len(authorIds) > 100 {
+		return nil, ErrTooManyIds
+	}
+	for _, id := range authorIds {
+		if err := ValidateId(id); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries manga.Slice
+	seen := make(map[string]bool)
+
+	for _, artists := range []bool{false, true} {
+		q := MangaQuery{
+			Limit:          100,
+			CreatedAtSince: since.UTC().Format(dateFormat),
+			Sort:           MangaSort{By: "createdAt", Order: "asc"},
+		}
+		if artists {
+			q.Artists = authorIds
+		} else {
+			q.Authors = authorIds
+		}
+
+		for {
+			data, info, err := SearchMangaEx(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, m := range data {
+				if !seen[m.ID] {
+					seen[m.ID] = true
+					entries = append(entries, m)
+				}
+			}
+			if len(data) == 0 || info.Offset+info.Limit >= info.Total || q.Offset+2*q.Limit > maxResultWindow {
+				break
+			}
+			q.Offset += info.Limit
+		}
+	}
+	return entries, nil
+}
+
 // Tags retrieves all tags
-func Tags() ([]*Tag, error) {
-	_ = limiter.Wait(context.Background())
+func Tags(ctx context.Context) ([]*Tag, error) {
+	wait(ctx, limiter)
 
-	buf, err := utils.Get(buildURL("manga/tag"))
+	buf, err := utils.Get(ctx, buildURL("manga/tag"))
 	if err != nil {
 		return nil, err
 	}
@@ -163,15 +213,15 @@ func Tags() ([]*Tag, error) {
 
 // TagsEx retrieves all tags
 //  - This function returns normalized data
-func TagsEx() ([]*tag.Tag, error) {
-	data, err := Tags()
+func TagsEx(ctx context.Context) ([]*tag.Tag, error) {
+	data, err := Tags(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var entries []*tag.Tag
 	for _, entry := range data {
-		if x := entry.Normalize(); data != nil {
+		if x := entry.Normalize(); x != nil {
 			entries = append(entries, x)
 		}
 	}
@@ -24,6 +24,16 @@ func (self *Manga) Normalize() *manga.Manga {
 		return nil
 	}
 
+	for _, alt := range self.Attributes.AltTitles {
+		if jSON, valid := alt.(map[string]any); valid {
+			for _, v := range jSON {
+				if title, valid := v.(string); valid && len(title) > 0 {
+					m.AltTitles = append(m.AltTitles, title)
+				}
+			}
+		}
+	}
+
 	parseLocalizations(self.Attributes.Description, &m.Description)
 	m.CreatedAt = utils.ParseDateString(self.Attributes.CreatedAt).Unix()
 	if len(self.Attributes.UpdatedAt) > 0 {
@@ -52,19 +62,8 @@ func (self *Manga) Normalize() *manga.Manga {
 		}
 	}
 
-	switch self.Attributes.OriginalLanguage {
-	case Languages.English.String():
-		m.Origin = Languages.English
-		break
-	case Languages.Chinese.String():
-		m.Origin = Languages.Chinese
-		break
-	case Languages.Japan.String():
-		m.Origin = Languages.Japan
-		break
-	case Languages.Korean.String():
-		m.Origin = Languages.Korean
-		break
+	if language, ok := ParseLanguage(self.Attributes.OriginalLanguage); ok {
+		m.Origin = language
 	}
 
 	switch self.Attributes.PublicationDemographic {
@@ -149,10 +148,11 @@ func (self *Manga) Normalize() *manga.Manga {
 }
 
 func (self *Tag) Normalize() *tag.Tag {
-	tag := &tag.Tag{ID: self.ID}
+	tag := &tag.Tag{ID: self.ID, Group: self.Attributes.Group}
 	if !parseLocalizations(self.Attributes.Name, &tag.Name) {
 		return nil
 	}
+	parseLocalizations(self.Attributes.Description, &tag.Description)
 	return tag
 }
 
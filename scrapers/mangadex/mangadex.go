@@ -5,6 +5,7 @@ import (
 	"time"
 
 	. "nonbiri/constants"
+	"nonbiri/utils"
 
 	"golang.org/x/time/rate"
 )
@@ -27,8 +28,9 @@ type Response[T any] struct {
 type ChapterPagesMetadata struct {
 	BaseURL string `json:"baseUrl"`
 	Chapter struct {
-		Data []string
-		Hash string
+		Data      []string
+		DataSaver []string
+		Hash      string
 	}
 }
 
@@ -38,12 +40,35 @@ type QueryResultInfo struct {
 	Total  int `json:"total"`
 }
 
-const baseURL = "https://api.mangadex.org"
+var baseURL = utils.NewEndpoint("https://api.mangadex.org")
+
+// Where the covers, and the pages no MangaDex@Home node serves, are retrieved
+var imagesURL = utils.NewEndpoint("https://uploads.mangadex.org")
+
+// SetBaseURL points the requests to another API, such as a mirror or a fake server
+func SetBaseURL(u string) {
+	baseURL.Set(u)
+}
+
+// SetImagesURL retrieves the images from another server, such as a mirror or a fake server
+func SetImagesURL(u string) {
+	imagesURL.Set(u)
+}
+
+func ImagesURL() string {
+	return imagesURL.String()
+}
+
+// Format of the createdAtSince, updatedAtSince and publishAtSince parameters
+const dateFormat = "2006-01-02T15:04:05"
+
+// Offset + limit of a listing can not go beyond this
+const maxResultWindow = 10000
 
 var limiter = rate.NewLimiter(rate.Every(time.Second/5), 1) // 5 requests/s
 
 func buildURL(pathname string, q ...*url.Values) string {
-	u, _ := url.Parse(baseURL)
+	u, _ := url.Parse(baseURL.String())
 	u.Path = pathname
 	if q != nil {
 		u.RawQuery = q[0].Encode()
@@ -51,7 +76,8 @@ func buildURL(pathname string, q ...*url.Values) string {
 	return u.String()
 }
 
-func validateId(id string) error {
+// ValidateId returns ErrInvalidId when id is not a MangaDex id
+func ValidateId(id string) error {
 	if len(id) != 36 {
 		return ErrInvalidId
 	}
@@ -11,6 +11,7 @@ type (
 
 	mAttrs struct {
 		Title       any
+		AltTitles   []any
 		Description any
 
 		Tags  []Tag
@@ -71,8 +72,9 @@ type (
 		ID         string
 		Type       string
 		Attributes struct {
-			Name  any
-			Group string
+			Name        any
+			Description any
+			Group       string
 		}
 	}
 )
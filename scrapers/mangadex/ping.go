@@ -0,0 +1,24 @@
+package mangadex
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"nonbiri/utils"
+)
+
+// Ping checks whether MangaDex is reachable
+func Ping(ctx context.Context) error {
+	wait(ctx, limiter)
+
+	buf, err := utils.Get(ctx, buildURL("ping"))
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(string(buf)) != "pong" {
+		return errors.New("unexpected ping response")
+	}
+	return nil
+}
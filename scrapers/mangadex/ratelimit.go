@@ -0,0 +1,91 @@
+package mangadex
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"nonbiri/utils"
+
+	"golang.org/x/time/rate"
+)
+
+// Requests of a limiter are held back until the time stored for it,
+// once the API has told its rate limit is exhausted.
+var pauses = struct {
+	sync.Mutex
+	until map[*rate.Limiter]time.Time
+}{until: make(map[*rate.Limiter]time.Time)}
+
+func init() {
+	utils.OnRateLimit(observeRateLimit)
+}
+
+// limiterOf returns the limiter the requests of the endpoint go through
+func limiterOf(pathname string) *rate.Limiter {
+	switch {
+	case strings.HasPrefix(pathname, "/at-home/"):
+		return atHomeLimiter
+	case pathname == "/auth/login":
+		return loginLimiter
+	case pathname == "/auth/refresh":
+		return refreshLimiter
+	default:
+		return limiter
+	}
+}
+
+// limiterName labels the metrics of the limiter
+func limiterName(l *rate.Limiter) string {
+	switch l {
+	case atHomeLimiter:
+		return "mangadex/at-home"
+	case loginLimiter:
+		return "mangadex/login"
+	case refreshLimiter:
+		return "mangadex/refresh"
+	default:
+		return "mangadex"
+	}
+}
+
+// observeRateLimit pauses the limiter of the endpoint until the API allows requests again,
+// once none remain.
+func observeRateLimit(u *url.URL, rl *utils.RateLimit) {
+	if !strings.HasPrefix(u.String(), baseURL.String()) {
+		return
+	}
+	l := limiterOf(u.Path)
+
+	if rl.Remaining > 0 || rl.RetryAfter.Before(time.Now()) {
+		return
+	}
+
+	pauses.Lock()
+	defer pauses.Unlock()
+	if rl.RetryAfter.After(pauses.until[l]) {
+		pauses.until[l] = rl.RetryAfter
+	}
+}
+
+// wait blocks until the limiter allows a request and is not paused,
+// or until ctx is done so the request fails right away.
+func wait(ctx context.Context, l *rate.Limiter) {
+	start := time.Now()
+	defer func() {
+		utils.ObserveLimiterWait(limiterName(l), time.Since(start))
+	}()
+
+	pauses.Lock()
+	until := pauses.until[l]
+	pauses.Unlock()
+
+	if d := time.Until(until); d > 0 {
+		if err := utils.Sleep(ctx, d); err != nil {
+			return
+		}
+	}
+	_ = l.Wait(ctx)
+}
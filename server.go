@@ -4,18 +4,23 @@ import (
 	_ "embed"
 
 	"bytes"
-	"io"
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"nonbiri/utils"
+	"nonbiri/utils/metrics"
 
 	. "nonbiri/constants"
+	"nonbiri/scrapers/mangadex"
+	"nonbiri/services"
 	"nonbiri/websocket"
 
 	"github.com/gin-gonic/gin"
@@ -32,6 +37,9 @@ var Instance *http.Server
 //go:embed view/src/index.html
 var html []byte
 
+//go:embed view/src/sw.js
+var serviceWorker []byte
+
 func StartServer() {
 	gin.SetMode(Mode)
 
@@ -39,7 +47,13 @@ func StartServer() {
 	router.Static("/assets", "./assets")
 
 	router.GET("/ws", websocket.Serve)
-	router.GET("/0/*p", createReverseProxy(AssetsBaseURL.MangaDex))
+	router.GET("/0/*p", reverseProxy)
+	router.GET("/feed/:format", serveFeed)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/sw.js", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/javascript; charset=UTF-8", serviceWorker)
+	})
+	registerOPDS(router)
 
 	router.NoRoute(func(c *gin.Context) {
 		c.Data(http.StatusOK, "text/html; charset=UTF-8", html)
@@ -51,23 +65,51 @@ func StartServer() {
 		WriteTimeout: 60 * time.Second,
 		ReadTimeout:  60 * time.Second,
 	}
-	if err := Instance.ListenAndServe(); err != nil {
+	if err := Instance.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		logger.Err.Fatalln(err)
 	}
 }
 
-func createReverseProxy(u string) func(c *gin.Context) {
-	return func(c *gin.Context) { reverseProxy(u, c) }
+// StopServer stops accepting connections and waits for the requests being served,
+// the remaining ones are closed once ctx is done.
+func StopServer(ctx context.Context) {
+	if err := Instance.Shutdown(ctx); err != nil {
+		logger.Err.Println(err)
+		_ = Instance.Close()
+	}
 }
 
-func reverseProxy(u string, c *gin.Context) {
+var (
+	cacheRequests = metrics.NewCounter("nonbiri_cache_requests_total", "Images requested from the cache by result.", "result")
+	cacheBytes    = metrics.NewCounter("nonbiri_cache_served_bytes_total", "Bytes of the images served by result.", "result")
+)
+
+// reverseProxy serves the images of MangaDex from the cache,
+// they are retrieved from the configured images endpoint when they are missing.
+func reverseProxy(c *gin.Context) {
+	result := "miss"
+	defer func() {
+		cacheRequests.Inc(result)
+		if size := c.Writer.Size(); size > 0 {
+			cacheBytes.Add(float64(size), result)
+		}
+	}()
+
 	path := c.Param("p")
 	if cachePath := filepath.Join(CacheDirectory, path); utils.IsFileExists(cachePath) {
+		result = "hit"
 		c.File(cachePath)
 		return
 	}
 
-	url, err := url.Parse(u)
+	// Pages are retrieved through the MangaDex@Home node of their chapter
+	if parts := strings.Split(strings.TrimPrefix(path, "/"), "/"); len(parts) == 3 &&
+		(parts[0] == "data" || parts[0] == "data-saver") {
+		servePage(c, parts[0], parts[1], parts[2])
+		return
+	}
+
+	url, err := url.Parse(mangadex.ImagesURL())
 	if err != nil {
 		c.String(http.StatusInternalServerError, err.Error())
 		return
@@ -75,7 +117,7 @@ func reverseProxy(u string, c *gin.Context) {
 	url.Path = path
 
 	proxy := httputil.NewSingleHostReverseProxy(url)
-	proxy.Transport = &FileTransport{http.DefaultTransport}
+	proxy.Transport = &FileTransport{utils.Transport}
 	proxy.Director = func(req *http.Request) {
 		req.Header = c.Request.Header
 		req.Host = url.Host
@@ -84,6 +126,96 @@ func reverseProxy(u string, c *gin.Context) {
 	proxy.ServeHTTP(c.Writer, c.Request)
 }
 
+func servePage(c *gin.Context, quality, hash, file string) {
+	localPath, err := services.CachePage(c.Request.Context(), quality, hash, file)
+	switch err {
+	case nil:
+		c.File(localPath)
+	case ErrInvalidPage:
+		c.String(http.StatusNotFound, err.Error())
+	case ErrOffline, ErrShuttingDown:
+		c.String(http.StatusServiceUnavailable, err.Error())
+	default:
+		c.String(http.StatusBadGateway, err.Error())
+	}
+}
+
+// serveFeed serves the library updates as /feed/atom or /feed/rss,
+// followState and language may be repeated to filter the chapters.
+func serveFeed(c *gin.Context) {
+	q := services.FeedQuery{Token: c.Query("token")}
+
+	switch c.Param("format") {
+	case "atom":
+		q.Atom = true
+	case "rss":
+	default:
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	for _, v := range c.QueryArray("followState") {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			c.String(http.StatusBadRequest, "invalid followState")
+			return
+		}
+		q.FollowStates = append(q.FollowStates, FollowState(n))
+	}
+
+	for _, v := range c.QueryArray("language") {
+		language, ok := parseLanguage(v)
+		if !ok {
+			c.String(http.StatusBadRequest, "invalid language")
+			return
+		}
+		q.Languages = append(q.Languages, language)
+	}
+
+	q.BaseURL = baseURL(c)
+
+	buf, err := services.Feed(q)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	contentType := "application/rss+xml; charset=UTF-8"
+	if q.Atom {
+		contentType = "application/atom+xml; charset=UTF-8"
+	}
+	c.Data(http.StatusOK, contentType, buf)
+}
+
+// baseURL returns the scheme and host the request has been sent to
+func baseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// abortWithError responds with the status matching the error of a feed
+func abortWithError(c *gin.Context, err error) {
+	switch err {
+	case ErrFeedDisabled, ErrMangaNotFound, ErrChapterNotFound, ErrChapterNotDownloaded:
+		c.String(http.StatusNotFound, err.Error())
+	case ErrInvalidFeedToken:
+		c.String(http.StatusUnauthorized, err.Error())
+	default:
+		c.String(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// parseLanguage accepts a language value or its code
+func parseLanguage(v string) (Language, bool) {
+	if n, err := strconv.Atoi(v); err == nil {
+		return Language(n), true
+	}
+	return ParseLanguage(v)
+}
+
 func (self *FileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	res, err := self.RoundTripper.RoundTrip(req)
 	if err != nil {
@@ -100,13 +232,7 @@ func (self *FileTransport) RoundTrip(req *http.Request) (*http.Response, error)
 		localPath := filepath.Join(CacheDirectory, req.URL.Path)
 		os.MkdirAll(filepath.Dir(localPath), os.ModePerm)
 
-		f, err := os.Create(localPath)
-		if err != nil {
-			return nil, err
-		}
-		defer f.Close()
-
-		if _, err = io.Copy(f, bytes.NewReader(buf)); err != nil {
+		if err := utils.WriteFile(buf, localPath); err != nil {
 			return nil, err
 		}
 	}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"nonbiri/models/manga"
 	"nonbiri/prefs"
 	"nonbiri/scrapers/mangadex"
@@ -8,10 +9,10 @@ import (
 	"github.com/rs1703/logger"
 )
 
-func Login(o mangadex.Authorization) (bool, error) {
+func Login(ctx context.Context, o mangadex.Authorization) (bool, error) {
 	defer logger.Track()()
 
-	token, err := mangadex.Login(o)
+	token, err := mangadex.Login(ctx, o)
 	if err != nil {
 		return false, err
 	}
@@ -23,10 +24,10 @@ func Login(o mangadex.Authorization) (bool, error) {
 	return true, nil
 }
 
-func RefreshToken() (bool, error) {
+func RefreshToken(ctx context.Context) (bool, error) {
 	defer logger.Track()()
 
-	token, err := mangadex.RefreshToken(prefs.Auth.RefreshToken)
+	token, err := mangadex.RefreshToken(ctx, prefs.Auth.RefreshToken)
 	if err != nil {
 		return false, err
 	}
@@ -38,12 +39,12 @@ func RefreshToken() (bool, error) {
 	return true, nil
 }
 
-func CheckToken() (bool, error) {
+func CheckToken(ctx context.Context) (bool, error) {
 	defer logger.Track()()
-	return mangadex.CheckToken(prefs.Auth.SessionToken)
+	return mangadex.CheckToken(ctx, prefs.Auth.SessionToken)
 }
 
-func GetFollows() (manga.Slice, error) {
+func GetFollows(ctx context.Context) (manga.Slice, error) {
 	defer logger.Track()()
 
 	token := &mangadex.Token{
@@ -51,7 +52,7 @@ func GetFollows() (manga.Slice, error) {
 		Refresh: prefs.Auth.RefreshToken,
 	}
 
-	data, err := mangadex.GetFollowsEx(token, mangadex.FollowQuery{})
+	data, err := mangadex.GetFollowsEx(ctx, token, mangadex.FollowQuery{})
 	if err != nil {
 		return nil, err
 	}
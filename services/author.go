@@ -0,0 +1,24 @@
+package services
+
+import (
+	"nonbiri/models/author"
+	"nonbiri/models/manga"
+
+	"github.com/rs1703/logger"
+)
+
+type AuthorData struct {
+	*author.Author
+	Entries manga.Slice `json:"entries"`
+}
+
+// GetAuthor retrieves an author and every known manga of the author
+func GetAuthor(id string) (*AuthorData, error) {
+	defer logger.Track()()
+
+	data, err := author.One(id)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthorData{Author: data, Entries: manga.ByAuthor(id)}, nil
+}
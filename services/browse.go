@@ -1,14 +1,13 @@
 package services
 
 import (
+	"context"
 	. "nonbiri/constants"
-	. "nonbiri/database"
 
 	"nonbiri/models/manga"
 	"nonbiri/models/tag"
 	"nonbiri/scrapers/mangadex"
 
-	"github.com/jmoiron/sqlx"
 	"github.com/rs1703/logger"
 )
 
@@ -40,69 +39,67 @@ type BrowseQuery struct {
 	Order Order `json:"order,omitempty"`
 }
 
-func Browse(q BrowseQuery) (*BrowseData, error) {
+func Browse(ctx context.Context, q BrowseQuery) (*BrowseData, error) {
 	defer logger.Track()()
 
-	data, info, err := mangadex.SearchMangaEx(q.Denormalize())
+	if !IsOnline() {
+		return browseLocal(q)
+	}
+
+	mq, err := q.Denormalize()
+	if err != nil {
+		return nil, err
+	}
+
+	data, info, err := mangadex.SearchMangaEx(ctx, mq)
 	if err != nil {
 		logger.Err.Println(err)
+		handleNetworkError(err)
 		return nil, err
 	}
 
 	if len(data) > 0 {
-		var mIds []string
-		mMap := make(manga.Map)
-
-		for _, m := range data {
-			mIds = append(mIds, m.ID)
-			mMap[m.ID] = m
-		}
-
-		tx, err := DB.Beginx()
-		if err != nil {
-			logger.Err.Println(err)
-			return nil, err
-		}
-
-		query, args, err := sqlx.In("SELECT * FROM manga WHERE id IN (?)", mIds)
-		if err != nil {
-			logger.Err.Println(err)
-			return nil, err
-		}
-
-		rows, err := tx.Queryx(tx.Rebind(query), args...)
-		if err != nil {
+		if err := mergeManga(ctx, data); err != nil {
 			logger.Err.Println(err)
 			return nil, err
 		}
+		cacheLibrary(false)
+	}
+	return &BrowseData{Entries: data, Query: q, QueryResultInfo: info}, nil
+}
 
-		for rows.Next() {
-			m := &manga.Manga{}
-			if err := rows.StructScan(m); err != nil {
-				logger.Err.Println(err)
-				return nil, err
-			}
-
-			m.Metadata = mMap[m.ID].Metadata
-			*mMap[m.ID] = *m
-
-			if _, err := m.UpdateMetadata(tx); err != nil {
-				logger.Err.Println(err)
-				return nil, err
-			}
-		}
+// browseLocal searches the manga stored in the database while MangaDex is unreachable
+func browseLocal(q BrowseQuery) (*BrowseData, error) {
+	if q.Limit == 0 {
+		q.Limit = 36
+	}
 
-		if err := tx.Commit(); err != nil {
-			logger.Err.Println(err)
-			return nil, err
-		}
+	f := manga.Filter{
+		Limit:           q.Limit,
+		Offset:          q.Offset,
+		Title:           q.Title,
+		Statuses:        q.Status,
+		Demographics:    q.Demographic,
+		Origins:         q.Origin,
+		ExcludedOrigins: q.ExcludedOrigin,
+		Ratings:         q.ContentRating,
+		IncludedTags:    q.IncludedTags,
+		ExcludedTags:    q.ExcludedTags,
+		Sort:            q.Sort,
+		Order:           q.Order,
+	}
 
-		cacheLibrary(false)
+	entries, _, total, err := f.Find()
+	if err != nil {
+		logger.Err.Println(err)
+		return nil, err
 	}
-	return &BrowseData{Entries: data, Query: q, QueryResultInfo: info}, nil
+
+	info := &mangadex.QueryResultInfo{Limit: q.Limit, Offset: q.Offset, Total: total}
+	return &BrowseData{Entries: entries, Query: q, QueryResultInfo: info}, nil
 }
 
-func (self *BrowseQuery) Denormalize() mangadex.MangaQuery {
+func (self *BrowseQuery) Denormalize() (mangadex.MangaQuery, error) {
 	if self.Limit == 0 {
 		self.Limit = 36
 	}
@@ -150,21 +147,19 @@ func (self *BrowseQuery) Denormalize() mangadex.MangaQuery {
 	}
 
 	for _, v := range self.IncludedTags {
-		if len(v) == 48 {
-			o.IncludedTags = append(o.IncludedTags, v)
-		} else if t, err := tag.One(v); err == nil {
-			o.IncludedTags = append(o.IncludedTags, t.ID)
+		t, err := tag.Resolve(v)
+		if err != nil {
+			return o, err
 		}
+		o.IncludedTags = append(o.IncludedTags, t.ID)
 	}
 
-	if len(self.ExcludedTags) > 0 {
-		for _, v := range self.ExcludedTags {
-			if len(v) == 48 {
-				o.ExcludedTags = append(o.ExcludedTags, v)
-			} else if t, err := tag.One(v); err == nil {
-				o.ExcludedTags = append(o.ExcludedTags, t.ID)
-			}
+	for _, v := range self.ExcludedTags {
+		t, err := tag.Resolve(v)
+		if err != nil {
+			return o, err
 		}
+		o.ExcludedTags = append(o.ExcludedTags, t.ID)
 	}
 
 	if len(self.ContentRating) > 0 {
@@ -173,5 +168,5 @@ func (self *BrowseQuery) Denormalize() mangadex.MangaQuery {
 		}
 	}
 
-	return o
+	return o, nil
 }
@@ -1,16 +1,27 @@
 package services
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	. "nonbiri/constants"
 	. "nonbiri/database"
 	"nonbiri/prefs"
+	"nonbiri/utils"
 
 	"nonbiri/models/chapter"
+	"nonbiri/models/manga"
 	"nonbiri/scrapers/mangadex"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rs1703/logger"
 )
 
+const lastCheckedOverlap = 10 * time.Minute
+
 func GetChapter(id string) (*chapter.Chapter, error) {
 	defer logger.Track()()
 
@@ -22,7 +33,7 @@ func GetChapter(id string) (*chapter.Chapter, error) {
 	return data, nil
 }
 
-func UpdateChapter(id string) (*chapter.Chapter, error) {
+func UpdateChapter(ctx context.Context, id string) (*chapter.Chapter, error) {
 	defer logger.Track()()
 
 	data, err := chapter.One(id)
@@ -34,8 +45,13 @@ func UpdateChapter(id string) (*chapter.Chapter, error) {
 		}
 	}
 
-	newData, err := mangadex.GetChapterEx(id)
+	if !IsOnline() {
+		return nil, enqueue(Tasks.UpdateChapter, id)
+	}
+
+	newData, err := mangadex.GetChapterEx(ctx, id)
 	if err != nil {
+		handleNetworkError(err)
 		return nil, err
 	}
 
@@ -44,6 +60,9 @@ func UpdateChapter(id string) (*chapter.Chapter, error) {
 	if len(newData.Pages) > 0 {
 		data.Pages = newData.Pages
 	}
+	if len(newData.DataSaverPages) > 0 {
+		data.DataSaverPages = newData.DataSaverPages
+	}
 
 	if _, err = data.UpdateMetadata(nil); err != nil {
 		return nil, err
@@ -59,25 +78,199 @@ func GetChapters(mangaId string) ([]*chapter.Chapter, error) {
 	return chapter.ByManga(mangaId), nil
 }
 
-func UpdateChapters(mangaId string, isUpdating bool) ([]*chapter.Chapter, error) {
+// GetBestChapters collapses the releases of the same chapter number into the one
+// in the best available language, then of the most preferred group.
+// Chapters of the blocked groups are left out.
+func GetBestChapters(mangaId string) ([]*chapter.Chapter, error) {
+	defer logger.Track()()
+
+	m, err := manga.ChapterPreferences(mangaId)
+	if err != nil {
+		return nil, err
+	}
+
+	p := chapter.Preference{
+		Languages: m.Languages,
+		Groups:    append(m.PreferredGroups.Ids(), prefs.Groups.Preferred.Ids()...),
+	}
+	if len(p.Languages) == 0 {
+		p.Languages = prefs.Browse.PreferredLanguages()
+	}
+	return withoutBlocked(chapter.ByManga(mangaId)).Best(p), nil
+}
+
+func UpdateChapters(ctx context.Context, mangaId string, isUpdating bool) ([]*chapter.Chapter, error) {
 	defer logger.Track()()
 
-	chapters := chapter.ByManga(mangaId)
-	newChapters, err := mangadex.GetChaptersEx(mangaId, mangadex.FeedQuery{
-		TranslatedLanguage: []string{prefs.Browse.Language.String()},
+	if !IsOnline() {
+		return nil, enqueue(Tasks.UpdateChapters, mangaId)
+	}
+
+	chapters, _, err := updateChapters(ctx, mangaId, isUpdating)
+	return chapters, err
+}
+
+// updateChapters retrieves the full feed of the manga,
+// returns the chapters which were not stored yet as well.
+func updateChapters(ctx context.Context, mangaId string, isUpdating bool) (chapters, added chapter.Slice, err error) {
+	if !IsOnline() {
+		return nil, nil, ErrOffline
+	}
+
+	checkedAt := time.Now().Unix()
+	chapters = chapter.ByManga(mangaId)
+	var languages manga.LanguageList
+	if m, err := manga.ChapterPreferences(mangaId); err == nil {
+		languages = m.Languages
+	} else if err != ErrMangaNotFound {
+		return nil, nil, err
+	}
+
+	newChapters, err := mangadex.GetChaptersEx(ctx, mangaId, mangadex.FeedQuery{
+		TranslatedLanguage: languageCodes(languages),
+	})
+	if err != nil {
+		handleNetworkError(err)
+		return nil, nil, err
+	}
+
+	tx, err := DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if added, err = mergeChapters(tx, chapters.Map(), newChapters); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	chapters = append(chapters, added...)
+	if err = manga.SetLastChecked(tx, checkedAt, mangaId); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	reschedule(mangaId)
+
+	// Every chapter is new the first time the feed is retrieved
+	if len(chapters) > len(added) {
+		emitChapters(added)
+	}
+
+	cacheLibrary(isUpdating)
+	cacheUpdates(isUpdating)
+	return chapters, added, nil
+}
+
+// updateChaptersSince retrieves the chapters of many manga at once,
+// only chapters updated since the manga were last checked are requested.
+// Manga which share the same languages are requested together,
+// the chapters of the languages saved before an error are returned with it.
+func updateChaptersSince(ctx context.Context, entries manga.Slice) (added chapter.Slice, err error) {
+	defer logger.Track()()
+
+	if !IsOnline() {
+		return nil, ErrOffline
+	}
+
+	var keys []string
+	batches := make(map[string]manga.Slice)
+	for _, m := range entries {
+		key := strings.Join(languageCodes(m.Languages), ",")
+		if _, ok := batches[key]; !ok {
+			keys = append(keys, key)
+		}
+		batches[key] = append(batches[key], m)
+	}
+
+	for _, key := range keys {
+		chapters, err := updateChaptersSinceIn(ctx, batches[key], strings.Split(key, ","))
+		if err != nil {
+			return added, err
+		}
+		added = append(added, chapters...)
+	}
+	return
+}
+
+// updateChaptersSinceIn retrieves the chapters translated in the languages
+func updateChaptersSinceIn(ctx context.Context, entries manga.Slice, languages []string) (chapter.Slice, error) {
+	var ids []string
+	since := time.Now()
+
+	for _, m := range entries {
+		ids = append(ids, m.ID)
+		if t := time.Unix(m.LastChecked, 0); t.Before(since) {
+			since = t
+		}
+	}
+
+	// Chapters may be indexed a little while after they were updated
+	since = since.Add(-lastCheckedOverlap)
+
+	checkedAt := time.Now().Unix()
+	newChapters, err := mangadex.GetChaptersSinceEx(ctx, ids, since, mangadex.ChapterQuery{
+		TranslatedLanguage: languages,
 	})
+	if err != nil {
+		handleNetworkError(err)
+		return nil, err
+	}
+
+	var cIds []string
+	for _, c := range newChapters {
+		cIds = append(cIds, c.ID)
+	}
+
+	tx, err := DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err := DB.Beginx()
+	added, err := mergeChapters(tx, chapter.ByIds(cIds).Map(), newChapters)
 	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = manga.SetLastChecked(tx, checkedAt, ids...); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	cMap := chapters.Map()
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	reschedule(ids...)
+
+	emitChapters(added)
+	return added, nil
+}
+
+// languageCodes returns the codes of the override,
+// or of the preferred languages when it is empty.
+func languageCodes(override manga.LanguageList) (result []string) {
+	languages := []Language(override)
+	if len(languages) == 0 {
+		languages = prefs.Browse.PreferredLanguages()
+	}
+	for _, language := range languages {
+		result = append(result, language.String())
+	}
+	return
+}
+
+// withoutBlocked leaves out the chapters released by the blocked groups
+func withoutBlocked(chapters chapter.Slice) chapter.Slice {
+	return chapters.WithoutGroups(prefs.Groups.Blocked.Ids())
+}
+
+// mergeChapters saves the retrieved chapters on top of the stored ones,
+// returns the chapters which were not stored yet.
+func mergeChapters(tx *sqlx.Tx, stored chapter.Map, newChapters chapter.Slice) (added chapter.Slice, err error) {
 	for _, next := range newChapters {
-		if prev, exists := cMap[next.ID]; exists {
+		if prev, exists := stored[next.ID]; exists {
 			if len(next.Hash) == 0 {
 				next.Hash = prev.Hash
 			}
@@ -85,24 +278,64 @@ func UpdateChapters(mangaId string, isUpdating bool) ([]*chapter.Chapter, error)
 			if len(next.Pages) > 0 {
 				prev.Pages = next.Pages
 			}
+			if len(next.DataSaverPages) > 0 {
+				prev.DataSaverPages = next.DataSaverPages
+			}
 			*next = *prev
 		} else {
-			chapters = append(chapters, next)
+			stored[next.ID] = next
+			added = append(added, next)
 		}
 
-		next.UpdateMetadata(tx)
+		if _, err := next.UpdateMetadata(tx); err != nil {
+			return nil, err
+		}
 	}
+	return
+}
 
-	if err = tx.Commit(); err != nil {
-		return nil, err
+// CachePage returns where the page is cached, quality is either "data" or "data-saver".
+// Missing pages are retrieved through the MangaDex@Home node of their chapter.
+func CachePage(ctx context.Context, quality, hash, file string) (string, error) {
+	path, ok := chapter.PagePath(quality, hash, file)
+	if !ok {
+		return "", ErrInvalidPage
+	}
+	if utils.IsFileExists(path) {
+		return path, nil
 	}
 
-	cacheLibrary(isUpdating)
-	cacheUpdates(isUpdating)
-	return chapters, nil
+	if !IsOnline() {
+		return "", ErrOffline
+	}
+	if !beginWork() {
+		return "", ErrShuttingDown
+	}
+	defer endWork()
+
+	ctx, cancel := withLifetime(ctx)
+	defer cancel()
+
+	var chapterId string
+	if data, err := chapter.ByHash(hash); err == nil {
+		chapterId = data.ID
+	} else if err != ErrChapterNotFound {
+		return "", err
+	}
+
+	buf, err := mangadex.FetchPage(ctx, chapterId, quality, hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err := utils.WriteFile(buf, path); err != nil {
+		return "", err
+	}
+	return path, nil
 }
 
-func GetPages(id string) (*chapter.Chapter, error) {
+func GetPages(ctx context.Context, id string) (*chapter.Chapter, error) {
 	defer logger.Track()()
 
 	data, err := chapter.One(id)
@@ -110,8 +343,17 @@ func GetPages(id string) (*chapter.Chapter, error) {
 		return nil, err
 	}
 
-	pages, err := mangadex.GetPages(id)
+	// Cached pages are still readable while offline
+	if !IsOnline() {
+		if len(data.Pages) > 0 && len(data.Hash) > 0 {
+			return data, nil
+		}
+		return nil, ErrOffline
+	}
+
+	pages, err := mangadex.GetPages(ctx, id)
 	if err != nil {
+		handleNetworkError(err)
 		return nil, err
 	}
 
@@ -120,6 +362,10 @@ func GetPages(id string) (*chapter.Chapter, error) {
 		data.Pages = pages.Chapter.Data
 		inc++
 	}
+	if len(pages.Chapter.DataSaver) > 0 {
+		data.DataSaverPages = pages.Chapter.DataSaver
+		inc++
+	}
 	if len(pages.Chapter.Hash) > 0 {
 		data.Hash = pages.Chapter.Hash
 		inc++
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	. "nonbiri/constants"
+
+	"nonbiri/models/chapter"
+	"nonbiri/models/update"
+	"nonbiri/prefs"
+	"nonbiri/scrapers/mangadex"
+	"nonbiri/utils"
+	"nonbiri/utils/cron"
+	"nonbiri/utils/mail"
+
+	"github.com/rs1703/logger"
+)
+
+// Digest is the email of the chapters discovered since the previous one
+type Digest struct {
+	// Zero for the first digest
+	Since    time.Time
+	Mangas   []*DigestManga
+	Chapters int
+}
+
+type DigestManga struct {
+	ID    string
+	Title string
+	URL   string
+	// Content-ID of the inline cover, empty when it could not be loaded
+	CoverID  string
+	Chapters []*DigestChapter
+}
+
+type DigestChapter struct {
+	Title  string
+	Groups string
+	URL    string
+}
+
+// Key of the last digest in the update schedules
+const digestKey = "digest"
+
+var digestMutex sync.Mutex
+
+var digestText = template.Must(template.New("text").Parse(`{{.Chapters}} new chapters{{if not .Since.IsZero}} since {{.Since.Format "Jan 2, 15:04"}}{{end}}
+{{range .Mangas}}
+{{.Title}}{{if .URL}}
+{{.URL}}{{end}}
+{{range .Chapters}}  - {{.Title}}{{if .Groups}} ({{.Groups}}){{end}}
+{{end}}{{end}}`))
+
+var digestHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222; max-width: 640px; margin: 0 auto;">
+<h2>{{.Chapters}} new chapters</h2>
+{{if not .Since.IsZero}}<p style="color: #666;">Since {{.Since.Format "Jan 2, 15:04"}}</p>{{end}}
+{{range .Mangas}}
+<table cellpadding="0" cellspacing="0" style="margin: 16px 0; border-collapse: collapse;">
+<tr>
+<td valign="top" style="width: 96px; padding-right: 12px;">{{if .CoverID}}<img src="cid:{{.CoverID}}" width="96" alt="">{{end}}</td>
+<td valign="top">
+<h3 style="margin: 0 0 8px;">{{if .URL}}<a href="{{.URL}}" style="color: #222;">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h3>
+<ul style="margin: 0; padding-left: 16px;">
+{{range .Chapters}}<li>{{if .URL}}<a href="{{.URL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}{{if .Groups}} <span style="color: #666;">{{.Groups}}</span>{{end}}</li>
+{{end}}</ul>
+</td>
+</tr>
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// SendDigest emails the chapters discovered since the last digest right away,
+// it returns the number of chapters which were sent.
+func SendDigest() (int, error) {
+	defer logger.Track()()
+
+	digestMutex.Lock()
+	defer digestMutex.Unlock()
+
+	since, err := update.LastRun(digestKey)
+	if err != nil {
+		return 0, err
+	}
+	return sendDigest(since, time.Now())
+}
+
+// TestEmail sends a short message to check the SMTP settings
+func TestEmail() error {
+	defer logger.Track()()
+
+	if err := checkEmail(); err != nil {
+		return err
+	}
+
+	msg := &mail.Message{
+		From:    prefs.Email.From,
+		To:      prefs.Email.To,
+		Subject: "Nonbiri - Test",
+		Text:    "Email digests are set up.",
+		HTML:    "<p>Email digests are set up.</p>",
+	}
+	return prefs.Email.SMTP.Send(msg)
+}
+
+func UpdateEmailPref(new *prefs.EmailPreference) (*prefs.EmailPreference, error) {
+	if _, err := cron.Parse(new.Schedule); err != nil {
+		return nil, err
+	}
+	new.BaseURL = strings.TrimSuffix(new.BaseURL, "/")
+	prefs.Email.Update(new)
+	return prefs.Email, nil
+}
+
+// runDigest sends the digest when its schedule is due,
+// a failed digest is retried by the next check.
+func runDigest() {
+	if !prefs.Email.Enabled {
+		return
+	}
+
+	digestMutex.Lock()
+	defer digestMutex.Unlock()
+
+	now := time.Now()
+	lastRun, err := update.LastRun(digestKey)
+	if err != nil {
+		logger.Err.Println(err)
+		return
+	}
+
+	if lastRun == 0 {
+		if err := update.SetLastRun(digestKey, now.Unix()); err != nil {
+			logger.Err.Println(err)
+		}
+		return
+	}
+
+	expr, err := cron.Parse(prefs.Email.Schedule)
+	if err != nil {
+		logger.Err.Println(digestKey, err)
+		return
+	}
+	if expr.Next(time.Unix(lastRun, 0)).After(now) {
+		return
+	}
+
+	if _, err := sendDigest(lastRun, now); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// sendDigest emails the chapters discovered within [since, until),
+// nothing is sent when there are none but the digest still moves forward.
+func sendDigest(since int64, until time.Time) (int, error) {
+	if err := checkEmail(); err != nil {
+		return 0, err
+	}
+
+	chapters, err := chapter.Discovered(since, until.Unix())
+	if err != nil {
+		return 0, err
+	}
+	chapters = withoutBlocked(chapters)
+
+	if len(chapters) > 0 {
+		var from time.Time
+		if since > 0 {
+			from = time.Unix(since, 0)
+		}
+
+		msg, err := newDigestMessage(newDigest(from, chapters))
+		if err != nil {
+			return 0, err
+		}
+		if err := prefs.Email.SMTP.Send(msg); err != nil {
+			return 0, err
+		}
+	}
+	return len(chapters), update.SetLastRun(digestKey, until.Unix())
+}
+
+// pruneDiscovered deletes the discovered chapters the digest has already sent,
+// and those older than keptDiscoveredAge in case it is never sent
+func pruneDiscovered() error {
+	lastRun, err := update.LastRun(digestKey)
+	if err != nil {
+		return err
+	}
+	return update.PruneChapters(lastRun, time.Now().Add(-keptDiscoveredAge).Unix())
+}
+
+func checkEmail() error {
+	if len(prefs.Email.SMTP.Host) == 0 || len(prefs.Email.From) == 0 || len(prefs.Email.To) == 0 {
+		return ErrEmailNotConfigured
+	}
+	return nil
+}
+
+// newDigest groups the chapters by manga, they are expected to be ordered by manga title
+func newDigest(since time.Time, chapters chapter.Slice) *Digest {
+	d := &Digest{Since: since, Chapters: len(chapters)}
+	baseURL := prefs.Email.BaseURL
+
+	var current *DigestManga
+	for _, c := range chapters {
+		if current == nil || current.ID != c.MangaId {
+			current = &DigestManga{ID: c.MangaId, Title: c.MangaTitle}
+			if len(baseURL) > 0 {
+				current.URL = fmt.Sprintf("%s/manga/%s", baseURL, c.MangaId)
+			}
+			if len(c.Cover) > 0 {
+				current.CoverID = c.Cover
+			}
+			d.Mangas = append(d.Mangas, current)
+		}
+
+		dc := &DigestChapter{Title: chapterTitle(c), Groups: groupNames(c)}
+		if len(baseURL) > 0 {
+			dc.URL = fmt.Sprintf("%s/read/%s/%s", baseURL, c.MangaId, c.ID)
+		}
+		current.Chapters = append(current.Chapters, dc)
+	}
+	return d
+}
+
+// newDigestMessage renders the digest, the covers are embedded as inline images
+func newDigestMessage(d *Digest) (*mail.Message, error) {
+	msg := &mail.Message{
+		From:    prefs.Email.From,
+		To:      prefs.Email.To,
+		Subject: fmt.Sprintf("Nonbiri - %d new chapters", d.Chapters),
+	}
+
+	for _, m := range d.Mangas {
+		if len(m.CoverID) == 0 {
+			continue
+		}
+
+		buf, err := loadCover(m.ID, m.CoverID)
+		if err != nil {
+			logger.Err.Println(m.ID, err)
+			m.CoverID = ""
+			continue
+		}
+		m.CoverID = fmt.Sprintf("%s@nonbiri", m.ID)
+		msg.Inlines = append(msg.Inlines, &mail.Inline{ID: m.CoverID, ContentType: "image/jpeg", Data: buf})
+	}
+
+	text := &bytes.Buffer{}
+	if err := digestText.Execute(text, d); err != nil {
+		return nil, err
+	}
+	msg.Text = text.String()
+
+	html := &bytes.Buffer{}
+	if err := digestHTML.Execute(html, d); err != nil {
+		return nil, err
+	}
+	msg.HTML = html.String()
+	return msg, nil
+}
+
+// loadCover reads the cover thumbnail from the cache, it is downloaded when it is missing
+func loadCover(mangaId, cover string) ([]byte, error) {
+	path := fmt.Sprintf("/covers/%s/%s.256.jpg", mangaId, cover)
+	localPath := filepath.Join(CacheDirectory, path)
+	if utils.IsFileExists(localPath) {
+		return utils.ReadFile(localPath)
+	}
+
+	buf, err := utils.Get(context.Background(), mangadex.ImagesURL()+path)
+	if err != nil {
+		return nil, err
+	}
+
+	os.MkdirAll(filepath.Dir(localPath), os.ModePerm)
+	if err := utils.WriteFile(buf, localPath); err != nil {
+		logger.Err.Println(err)
+	}
+	return buf, nil
+}
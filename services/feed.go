@@ -0,0 +1,144 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	. "nonbiri/constants"
+	"nonbiri/utils"
+
+	"nonbiri/models/chapter"
+	"nonbiri/prefs"
+	"nonbiri/utils/feed"
+
+	"github.com/rs1703/logger"
+)
+
+type FeedQuery struct {
+	Token        string
+	Atom         bool
+	FollowStates []FollowState
+	Languages    []Language
+
+	// Scheme and host the links of the feed point to
+	BaseURL string
+}
+
+// Feed encodes the latest chapters of the followed manga as Atom or RSS
+func Feed(q FeedQuery) ([]byte, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(q.Token); err != nil {
+		return nil, err
+	}
+
+	chapters, err := Updates(false)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]FollowState)
+	for _, m := range Library(false) {
+		states[m.ID] = m.FollowState
+	}
+
+	f := &feed.Feed{
+		ID:    q.BaseURL + "/feed",
+		Title: "Nonbiri - Updates",
+		Link:  q.BaseURL + "/updates",
+	}
+
+	for _, c := range chapters {
+		if len(q.FollowStates) > 0 && !utils.Contains(q.FollowStates, states[c.MangaId]) {
+			continue
+		}
+		if len(q.Languages) > 0 && !utils.Contains(q.Languages, c.Language) {
+			continue
+		}
+
+		item := &feed.Item{
+			ID:        "urn:mangadex:chapter:" + c.ID,
+			Title:     strings.TrimSpace(c.MangaTitle + " " + chapterTitle(c)),
+			Link:      fmt.Sprintf("%s/read/%s/%s", q.BaseURL, c.MangaId, c.ID),
+			Author:    groupNames(c),
+			Published: time.Unix(c.PublishAt, 0),
+		}
+		if len(c.Cover) > 0 {
+			item.Image = fmt.Sprintf("%s/0/covers/%s/%s.256.jpg", q.BaseURL, c.MangaId, c.Cover)
+		}
+		if len(item.Author) > 0 {
+			item.Summary = "Translated by " + item.Author
+		}
+
+		if item.Published.After(f.Updated) {
+			f.Updated = item.Published
+		}
+		f.Items = append(f.Items, item)
+	}
+
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+
+	if q.Atom {
+		return f.Atom()
+	}
+	return f.RSS()
+}
+
+// UpdateFeedPref stores the preference, a new token is generated when it is empty
+func UpdateFeedPref(new *prefs.FeedPreference) (*prefs.FeedPreference, error) {
+	if len(new.Token) == 0 {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		new.Token = hex.EncodeToString(buf)
+	}
+	prefs.Feed.Update(new)
+	return prefs.Feed, nil
+}
+
+// checkFeedToken ensures the feeds are enabled and the token is the expected one
+func checkFeedToken(token string) error {
+	if !prefs.Feed.Enabled {
+		return ErrFeedDisabled
+	}
+	if len(prefs.Feed.Token) == 0 || subtle.ConstantTimeCompare([]byte(token), []byte(prefs.Feed.Token)) != 1 {
+		return ErrInvalidFeedToken
+	}
+	return nil
+}
+
+// chapterTitle formats the chapter as Vol. 1 Ch. 2 - Title
+func chapterTitle(c *chapter.Chapter) string {
+	var parts []string
+	if len(c.Volume) > 0 {
+		parts = append(parts, "Vol. "+c.Volume)
+	}
+	if len(c.Chapter) > 0 {
+		parts = append(parts, "Ch. "+c.Chapter)
+	}
+	if len(c.Title) > 0 {
+		if len(parts) > 0 {
+			parts = append(parts, "-")
+		}
+		parts = append(parts, c.Title)
+	}
+	if len(parts) == 0 {
+		return "Oneshot"
+	}
+	return strings.Join(parts, " ")
+}
+
+func groupNames(c *chapter.Chapter) string {
+	var names []string
+	for _, g := range c.Groups {
+		names = append(names, g.Name)
+	}
+	return strings.Join(names, ", ")
+}
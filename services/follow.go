@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "nonbiri/constants"
+	"nonbiri/websocket"
+
+	"nonbiri/models/follow"
+	"nonbiri/models/manga"
+	"nonbiri/scrapers/mangadex"
+	"nonbiri/utils"
+
+	"github.com/rs1703/logger"
+)
+
+// Number of releases kept in the feed of the follows
+const keptFollowReleases = 500
+
+// How far back the first check of a follow looks
+const firstFollowCheck = 7 * 24 * time.Hour
+
+var followChecker sync.Mutex
+
+func GetEntityFollows() follow.Slice {
+	defer logger.Track()()
+	return follow.All()
+}
+
+// FollowEntity follows a scanlation group or an author,
+// their releases are checked after every library update.
+func FollowEntity(f *follow.Follow) (*follow.Follow, error) {
+	defer logger.Track()()
+
+	if f.Type != Entities.Group && f.Type != Entities.Author {
+		return nil, ErrInvalidFollowType
+	}
+	if len(f.ID) == 0 {
+		return nil, ErrFollowNotFound
+	}
+	if err := mangadex.ValidateId(f.ID); err != nil {
+		return nil, err
+	}
+	if err := f.Save(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func UnfollowEntity(id string) error {
+	defer logger.Track()()
+
+	if _, err := follow.One(id); err != nil {
+		return err
+	}
+	return follow.Delete(id)
+}
+
+// GetFollowedReleases returns the feed of the follows, the newest discovered first
+func GetFollowedReleases(limit int) follow.Releases {
+	defer logger.Track()()
+
+	if limit <= 0 {
+		limit = 100
+	}
+	return follow.Latest(limit)
+}
+
+// CheckFollows retrieves the new releases of the follows right away
+func CheckFollows(ctx context.Context) (follow.Releases, error) {
+	defer logger.Track()()
+
+	if !IsOnline() {
+		return nil, ErrOffline
+	}
+	if err := checkFollows(ctx); err != nil {
+		return nil, err
+	}
+	return follow.Latest(100), nil
+}
+
+// checkFollows retrieves the chapters of the followed groups
+// and the manga of the followed authors created since they were last checked.
+func checkFollows(ctx context.Context) error {
+	followChecker.Lock()
+	defer followChecker.Unlock()
+
+	groups := follow.ByType(Entities.Group)
+	authors := follow.ByType(Entities.Author)
+	if len(groups) == 0 && len(authors) == 0 {
+		return nil
+	}
+
+	// A failing batch does not hold back the others, their errors are returned together
+	// and its follows are retried from the same point on the next check.
+	var errs []error
+	for _, batch := range chunkFollows(groups) {
+		if err := checkGroups(ctx, batch); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			handleNetworkError(err)
+			errs = append(errs, err)
+		}
+	}
+	for _, batch := range chunkFollows(authors) {
+		if err := checkAuthors(ctx, batch); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			handleNetworkError(err)
+			errs = append(errs, err)
+		}
+	}
+
+	if err := follow.Prune(keptFollowReleases); err != nil {
+		logger.Err.Println(err)
+	}
+
+	websocket.Broadcast <- &websocket.OutgoingMessage{
+		Task: Tasks.GetFollowedReleases,
+	}
+	return utils.JoinErrors(errs...)
+}
+
+// chunkFollows splits the follows into batches of the size MangaDex accepts at once
+func chunkFollows(follows follow.Slice) (result []follow.Slice) {
+	for len(follows) > 100 {
+		result = append(result, follows[:100])
+		follows = follows[100:]
+	}
+	if len(follows) > 0 {
+		result = append(result, follows)
+	}
+	return
+}
+
+// followsSince returns the earliest time the follows have been checked at
+func followsSince(follows follow.Slice) (ids []string, since time.Time) {
+	since = time.Now()
+	for _, f := range follows {
+		ids = append(ids, f.ID)
+
+		t := time.Unix(f.LastChecked, 0)
+		if f.LastChecked == 0 {
+			t = time.Unix(f.FollowedAt, 0).Add(-firstFollowCheck)
+		}
+		if t.Before(since) {
+			since = t
+		}
+	}
+
+	// Releases may be indexed a little while after they were created
+	since = since.Add(-lastCheckedOverlap)
+	return
+}
+
+func checkGroups(ctx context.Context, follows follow.Slice) error {
+	ids, since := followsSince(follows)
+	checkedAt := time.Now().Unix()
+
+	chapters, err := mangadex.GetChaptersSinceEx(ctx, nil, since, mangadex.ChapterQuery{
+		Groups:             ids,
+		TranslatedLanguage: languageCodes(nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	var mangaIds []string
+	for _, c := range chapters {
+		mangaIds = append(mangaIds, c.MangaId)
+	}
+	titles, err := releaseManga(ctx, mangaIds)
+	if err != nil {
+		return err
+	}
+
+	var releases follow.Releases
+	for _, c := range chapters {
+		// Updated chapters are retrieved as well, only the new ones are releases
+		if time.Unix(c.CreatedAt, 0).Before(since) {
+			continue
+		}
+
+		m := titles[c.MangaId]
+		for _, id := range ids {
+			if !c.HasGroup(id) {
+				continue
+			}
+
+			r := &follow.Release{
+				ID:        c.ID,
+				FollowID:  id,
+				MangaID:   c.MangaId,
+				Title:     c.Title,
+				Volume:    c.Volume,
+				Chapter:   c.Chapter,
+				Language:  c.Language,
+				PublishAt: c.PublishAt,
+			}
+			if m != nil {
+				r.MangaTitle = m.Title
+				r.Cover = m.Cover
+			}
+			releases = append(releases, r)
+		}
+	}
+	return follow.SaveReleases(releases, checkedAt, ids)
+}
+
+func checkAuthors(ctx context.Context, follows follow.Slice) error {
+	ids, since := followsSince(follows)
+	checkedAt := time.Now().Unix()
+
+	data, err := mangadex.GetMangaSince(ctx, ids, since)
+	if err != nil {
+		return err
+	}
+
+	var releases follow.Releases
+	for _, m := range data {
+		for _, id := range ids {
+			if !m.Authors.Has(id) && !m.Artists.Has(id) {
+				continue
+			}
+			releases = append(releases, &follow.Release{
+				ID:         m.ID,
+				FollowID:   id,
+				MangaID:    m.ID,
+				MangaTitle: m.Title,
+				Cover:      m.Cover,
+				PublishAt:  m.CreatedAt,
+			})
+		}
+	}
+	return follow.SaveReleases(releases, checkedAt, ids)
+}
+
+// releaseManga retrieves the titles and covers of the manga, which may not be stored
+func releaseManga(ctx context.Context, ids []string) (manga.Map, error) {
+	result := make(manga.Map)
+
+	var missing []string
+	for _, id := range ids {
+		if _, ok := result[id]; ok {
+			continue
+		}
+		if m, err := manga.One(id, false); err == nil {
+			result[id] = m
+		} else {
+			result[id] = nil
+			missing = append(missing, id)
+		}
+	}
+
+	for len(missing) > 0 {
+		n := len(missing)
+		if n > 100 {
+			n = 100
+		}
+
+		data, _, err := mangadex.SearchMangaEx(ctx, mangadex.MangaQuery{
+			Limit:         n,
+			Ids:           missing[:n],
+			ContentRating: []string{"safe", "suggestive", "erotica", "pornographic"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range data {
+			result[m.ID] = m
+		}
+		missing = missing[n:]
+	}
+	return result, nil
+}
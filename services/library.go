@@ -1,28 +1,44 @@
 package services
 
 import (
-	"sync"
-	"time"
+	"encoding/base64"
 
 	. "nonbiri/constants"
-	. "nonbiri/database"
+	"nonbiri/utils"
 
+	"nonbiri/models/chapter"
 	"nonbiri/models/manga"
 
 	"nonbiri/prefs"
-	"nonbiri/websocket"
 
 	"github.com/rs1703/logger"
 )
 
-type UpdateState struct {
-	Progress int    `json:"progress"`
-	Total    int    `json:"total"`
-	Current  string `json:"current"`
+type LibraryData struct {
+	Entries manga.Slice  `json:"entries"`
+	Query   LibraryQuery `json:"query"`
+	Next    string       `json:"next,omitempty"`
+	Total   int          `json:"total"`
+}
+
+type LibraryQuery struct {
+	Limit        int           `json:"limit,omitempty"`
+	Cursor       string        `json:"cursor,omitempty"`
+	Title        string        `json:"title,omitempty"`
+	FollowState  []FollowState `json:"followState,omitempty"`
+	Status       []Status      `json:"status,omitempty"`
+	Demographic  []Demographic `json:"demographic,omitempty"`
+	Origin       []Language    `json:"origin,omitempty"`
+	IncludedTags []string      `json:"includedTag,omitempty"`
+	ExcludedTags []string      `json:"excludedTag,omitempty"`
+	HasUnread    bool          `json:"hasUnread,omitempty"`
+	Downloaded   bool          `json:"downloaded,omitempty"`
+
+	Sort  Sort  `json:"sort,omitempty"`
+	Order Order `json:"order,omitempty"`
 }
 
 var lCache manga.Slice
-var updateState *UpdateState
 
 func Library(isCaching bool) manga.Slice {
 	var track func()
@@ -40,104 +56,72 @@ func Library(isCaching bool) manga.Slice {
 	return lCache
 }
 
-func UpdateLibrary() *UpdateState {
-	if updateState != nil {
-		return updateState
-	}
-
-	updateState = &UpdateState{}
-
-	prefs.Library.LastUpdated = time.Now().Unix()
-	prefs.Library.Update(nil)
-
-	go func() {
-		var err error
-		defer func() {
-			updateState = nil
-		}()
-
-		q := `SELECT id, title FROM manga
-					WHERE manga.followed = TRUE`
+// FilterLibrary filters, sorts and paginates the library,
+// sort and order fallback to the library preference.
+func FilterLibrary(q LibraryQuery) (*LibraryData, error) {
+	defer logger.Track()()
 
-		follows := manga.Slice{}
-		if err = DB.Select(&follows, q); err != nil {
-			logger.Err.Println(err)
-			return
-		}
-
-		updateState.Total = len(follows)
-		if updateState.Total == 0 {
-			return
-		}
+	f, err := q.Denormalize()
+	if err != nil {
+		return nil, err
+	}
 
-		for _, entry := range follows {
-			updateState.Current = entry.Title
-			websocket.Broadcast <- &websocket.OutgoingMessage{
-				Task: Tasks.GetUpdateLibraryState,
-				Body: updateState,
-			}
-
-			if _, err = UpdateManga(entry.ID, true); err != nil {
-				logger.Err.Println(entry.ID, err)
-			}
-			updateState.Progress++
-		}
+	entries, next, total, err := f.Find()
+	if err != nil {
+		logger.Err.Println(err)
+		return nil, err
+	}
 
-		websocket.Broadcast <- &websocket.OutgoingMessage{
-			Task: Tasks.GetUpdateLibraryState,
+	data := &LibraryData{Entries: entries, Query: q, Total: total}
+	if next != nil {
+		buf, err := utils.Marshal(next)
+		if err != nil {
+			return nil, err
 		}
-
-		prefs.Library.LastUpdated = time.Now().Unix()
-		prefs.Library.Update(nil)
-
-		cacheLibrary(false)
-		cacheUpdates(false)
-	}()
-
-	return updateState
-}
-
-func GetUpdateLibraryState() *UpdateState {
-	return updateState
-}
-
-var scheduler = struct {
-	Ticker *time.Ticker
-	Done   chan bool
-	sync.Mutex
-}{
-	Done: make(chan bool),
+		data.Next = base64.RawURLEncoding.EncodeToString(buf)
+	}
+	return data, nil
 }
 
-func ScheduleUpdate() {
-	scheduler.Lock()
-	defer scheduler.Unlock()
-
-	if scheduler.Ticker != nil {
-		scheduler.Done <- true
+func (self *LibraryQuery) Denormalize() (*manga.Filter, error) {
+	if self.Sort == 0 {
+		self.Sort = prefs.Library.Sort
 	}
 
-	frequency := prefs.Library.UpdateFrequency * time.Hour
-	lastUpdated := time.Unix(prefs.Library.LastUpdated, 0)
+	if self.Order == 0 {
+		self.Order = prefs.Library.Order
+	}
 
-	if time.Now().After(lastUpdated.Add(frequency)) {
-		UpdateLibrary()
+	f := &manga.Filter{
+		Limit:        self.Limit,
+		Title:        self.Title,
+		Followed:     true,
+		FollowStates: self.FollowState,
+		Statuses:     self.Status,
+		Demographics: self.Demographic,
+		Origins:      self.Origin,
+		IncludedTags: self.IncludedTags,
+		ExcludedTags: self.ExcludedTags,
+		HasUnread:    self.HasUnread,
+		Sort:         self.Sort,
+		Order:        self.Order,
 	}
 
-	scheduler.Ticker = time.NewTicker(frequency)
-	go func() {
-		for {
-			select {
-			case <-scheduler.Done:
-				scheduler.Ticker.Stop()
-				return
-			case <-scheduler.Ticker.C:
-				if updateState == nil {
-					UpdateLibrary()
-				}
-			}
+	if len(self.Cursor) > 0 {
+		buf, err := base64.RawURLEncoding.DecodeString(self.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		f.Cursor = &manga.Cursor{}
+		if err := utils.Unmarshal(buf, f.Cursor); err != nil {
+			return nil, ErrInvalidCursor
 		}
-	}()
+	}
+
+	if self.Downloaded {
+		f.Downloaded = chapter.CachedHashes()
+	}
+	return f, nil
 }
 
 func cacheLibrary(isUpdating bool) {
@@ -1,15 +1,20 @@
 package services
 
 import (
+	"context"
 	"time"
 
 	. "nonbiri/constants"
+	. "nonbiri/database"
 	"nonbiri/utils"
 
+	"nonbiri/models/chapter"
+	"nonbiri/models/entity"
 	"nonbiri/models/manga"
 	"nonbiri/scrapers/anilist"
 	"nonbiri/scrapers/mangadex"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rs1703/logger"
 )
 
@@ -24,9 +29,42 @@ func GetManga(id string) (*manga.Manga, error) {
 	return data, nil
 }
 
-func UpdateManga(id string, isUpdating bool) (*manga.Manga, error) {
+func UpdateManga(ctx context.Context, id string, isUpdating bool) (*manga.Manga, error) {
 	defer logger.Track()()
 
+	if !IsOnline() {
+		return nil, enqueue(Tasks.UpdateManga, id)
+	}
+
+	data, _, err := updateManga(ctx, id, isUpdating)
+	return data, err
+}
+
+// updateManga refreshes the metadata and chapters of the manga,
+// returns the chapters which were not stored yet as well.
+func updateManga(ctx context.Context, id string, isUpdating bool) (*manga.Manga, chapter.Slice, error) {
+	data, err := updateMetadata(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var added chapter.Slice
+	data.Chapters, added, err = updateChapters(ctx, data.ID, isUpdating)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(data.Chapters) > 0 {
+		data.Chapters.SortByLatest()
+		data.LatestChapterAt = utils.NullableInt64(data.Chapters[0].PublishAt)
+		data.Chapters.SortByChapter()
+	}
+
+	return data, added, nil
+}
+
+// updateMetadata refreshes the metadata of the manga without its chapters
+func updateMetadata(ctx context.Context, id string) (*manga.Manga, error) {
 	data, err := manga.One(id, false)
 	if err != nil {
 		if err == ErrMangaNotFound {
@@ -36,14 +74,20 @@ func UpdateManga(id string, isUpdating bool) (*manga.Manga, error) {
 		}
 	}
 
-	newData, err := mangadex.GetMangaEx(id)
+	if !IsOnline() {
+		return nil, ErrOffline
+	}
+
+	newData, err := mangadex.GetMangaEx(ctx, id)
 	if err != nil {
+		handleNetworkError(err)
 		return nil, err
 	}
+	previous := data.Status
 	data.Metadata = newData.Metadata
 
 	if len(data.Banner) <= 1 && len(data.Links.AniList) > 0 {
-		banner, err := anilist.GetBanner(data.Links.AniList)
+		banner, err := anilist.GetBanner(ctx, data.Links.AniList)
 		if err == nil {
 			data.Banner = banner
 		} else {
@@ -51,23 +95,117 @@ func UpdateManga(id string, isUpdating bool) (*manga.Manga, error) {
 		}
 	}
 
-	_, err = data.UpdateMetadata(nil)
-	if err != nil {
+	if _, err = data.UpdateMetadata(nil); err != nil {
 		return nil, err
 	}
+	emitStatus(data, previous)
+	return data, nil
+}
+
+// updateMangaBatch refreshes the metadata of up to 100 manga in a single request
+// and retrieves their chapters updated since they were last checked,
+// returns the manga which are no longer available on MangaDex as well.
+func updateMangaBatch(ctx context.Context, entries manga.Slice, kind UpdateKind) (added chapter.Slice, missing manga.Slice, err error) {
+	defer logger.Track()()
+
+	if !IsOnline() {
+		return nil, nil, ErrOffline
+	}
 
-	data.Chapters, err = UpdateChapters(data.ID, isUpdating)
+	if kind == UpdateKinds.Chapters {
+		added, err = updateChaptersSince(ctx, entries)
+		return
+	}
+
+	ids := entries.Map().GetIds()
+	data, _, err := mangadex.SearchMangaEx(ctx, mangadex.MangaQuery{
+		Limit:         len(ids),
+		Ids:           ids,
+		ContentRating: []string{"safe", "suggestive", "erotica", "pornographic"},
+	})
 	if err != nil {
-		return nil, err
+		handleNetworkError(err)
+		return nil, nil, err
 	}
 
-	if len(data.Chapters) > 0 {
-		data.Chapters.SortByLatest()
-		data.LatestChapterAt = utils.NullableInt64(data.Chapters[0].PublishAt)
-		data.Chapters.SortByChapter()
+	if err := mergeManga(ctx, data); err != nil {
+		return nil, nil, err
 	}
 
-	return data, nil
+	found := data.Map()
+	var available manga.Slice
+	for _, m := range entries {
+		if _, ok := found[m.ID]; ok {
+			available = append(available, m)
+		} else {
+			missing = append(missing, m)
+		}
+	}
+
+	if len(available) > 0 && kind != UpdateKinds.Metadata {
+		added, err = updateChaptersSince(ctx, available)
+	}
+	return
+}
+
+// mergeManga stores the retrieved metadata of the manga which already exist
+func mergeManga(ctx context.Context, data manga.Slice) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var mIds []string
+	mMap := make(manga.Map)
+
+	for _, m := range data {
+		mIds = append(mIds, m.ID)
+		mMap[m.ID] = m
+	}
+
+	tx, err := DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM manga WHERE id IN (?)", mIds)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var stored manga.Slice
+	if err := tx.Select(&stored, tx.Rebind(query), args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	previous := make(map[string]Status)
+	for _, m := range stored {
+		previous[m.ID] = m.Status
+		m.Metadata = mMap[m.ID].Metadata
+		*mMap[m.ID] = *m
+
+		if _, err := m.UpdateMetadata(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, m := range stored {
+		emitStatus(m, previous[m.ID])
+	}
+	return nil
+}
+
+// emitStatus emits an event when the status of the followed manga has changed
+func emitStatus(m *manga.Manga, previous Status) {
+	if m.Followed && previous > 0 && previous != m.Status {
+		emit(Events.StatusChanged, &WebhookPayload{Manga: m, PreviousStatus: previous})
+	}
 }
 
 func FollowManga(id string, followState FollowState) (*manga.Manga, error) {
@@ -78,6 +216,7 @@ func FollowManga(id string, followState FollowState) (*manga.Manga, error) {
 		return nil, err
 	}
 
+	followed := data.Followed
 	data.Followed = true
 	data.FollowState = followState
 	if data.FollowedAt == 0 {
@@ -87,12 +226,74 @@ func FollowManga(id string, followState FollowState) (*manga.Manga, error) {
 	if _, err = data.UpdateFollowState(nil); err != nil {
 		return nil, err
 	}
+	reschedule(id)
+
+	if !followed {
+		emit(Events.MangaFollowed, &WebhookPayload{Manga: data})
+	}
 
 	cacheLibrary(false)
 	cacheUpdates(false)
 	return data, nil
 }
 
+// SetUpdateMode overrides how often the chapters of the manga are checked
+func SetUpdateMode(id string, mode UpdateMode) (*manga.Manga, error) {
+	defer logger.Track()()
+
+	data, err := manga.One(id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	data.UpdateMode = mode
+	if _, err = data.UpdateSchedule(nil); err != nil {
+		return nil, err
+	}
+	reschedule(id)
+
+	return manga.One(id, true)
+}
+
+// SetMangaLanguages overrides the languages of the chapters retrieved for the manga,
+// the preferred languages are used again when it is empty.
+func SetMangaLanguages(id string, languages []Language) (*manga.Manga, error) {
+	defer logger.Track()()
+
+	for _, language := range languages {
+		if !language.IsValid() {
+			return nil, ErrInvalidLanguage
+		}
+	}
+
+	data, err := manga.One(id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Languages = languages
+	if err = data.UpdateLanguages(); err != nil {
+		return nil, err
+	}
+	return manga.One(id, true)
+}
+
+// SetMangaGroups sets the groups whose releases are chosen first for the manga
+func SetMangaGroups(id string, groups entity.Slice) (*manga.Manga, error) {
+	defer logger.Track()()
+
+	data, err := manga.One(id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PreferredGroups = groups
+	if err = data.UpdatePreferredGroups(); err != nil {
+		return nil, err
+	}
+	return manga.One(id, true)
+}
+
 func UnfollowManga(id string) (*manga.Manga, error) {
 	defer logger.Track()()
 
@@ -101,6 +302,7 @@ func UnfollowManga(id string) (*manga.Manga, error) {
 		return nil, err
 	}
 
+	followed := data.Followed
 	data.Followed = false
 	data.FollowState = FollowStates.None
 	data.FollowedAt = 0
@@ -109,6 +311,10 @@ func UnfollowManga(id string) (*manga.Manga, error) {
 		return nil, err
 	}
 
+	if followed {
+		emit(Events.MangaUnfollowed, &WebhookPayload{Manga: data})
+	}
+
 	cacheLibrary(false)
 	cacheUpdates(false)
 	return data, nil
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	. "nonbiri/constants"
+	"nonbiri/utils"
+
+	"nonbiri/models/queue"
+	"nonbiri/models/webhook"
+	"nonbiri/prefs"
+	"nonbiri/scrapers/anilist"
+	"nonbiri/scrapers/mangadex"
+	"nonbiri/utils/webpush"
+	"nonbiri/websocket"
+
+	"github.com/rs1703/logger"
+)
+
+type NetworkState struct {
+	Online  bool `json:"online"`
+	Offline bool `json:"offline"` // Offline mode is enabled
+	Queued  int  `json:"queued"`
+}
+
+const networkCheckFrequency = time.Minute
+
+var network = struct {
+	connected bool
+	sync.RWMutex
+}{connected: true}
+
+var queueMutex = sync.Mutex{}
+
+// IsOnline reports whether MangaDex is reachable and offline mode is disabled
+func IsOnline() bool {
+	if prefs.Network.Offline {
+		return false
+	}
+
+	network.RLock()
+	defer network.RUnlock()
+	return network.connected
+}
+
+func GetNetworkState() *NetworkState {
+	return &NetworkState{
+		Online:  IsOnline(),
+		Offline: prefs.Network.Offline,
+		Queued:  queue.Count(),
+	}
+}
+
+// MonitorNetwork checks the connection to MangaDex once per networkCheckFrequency,
+// until the shutdown has started.
+func MonitorNetwork() {
+	ticker := time.NewTicker(networkCheckFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lifetime.stopping.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !prefs.Network.Offline && !isQuiet() {
+			CheckNetwork()
+		}
+	}
+}
+
+// CheckNetwork pings MangaDex and updates the network state
+func CheckNetwork() bool {
+	err := mangadex.Ping(context.Background())
+	if err != nil {
+		logger.Err.Println(err)
+	}
+	setConnected(err == nil)
+	return err == nil
+}
+
+// handleNetworkError marks MangaDex as unreachable when err is a network error
+func handleNetworkError(err error) {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		setConnected(false)
+	}
+}
+
+func setConnected(v bool) {
+	network.Lock()
+	changed := network.connected != v
+	network.connected = v
+	network.Unlock()
+
+	if changed {
+		broadcastNetworkState()
+		if IsOnline() {
+			go processQueue()
+		}
+	}
+}
+
+func init() {
+	// Deliveries of webhooks and push notifications go through the proxy as well
+	webhook.Client.Transport = utils.Transport
+	webpush.Client.Transport = utils.Transport
+
+	// The config file may be edited while running
+	prefs.OnReload(func() {
+		if err := ApplyNetworkPref(); err != nil {
+			logger.Err.Println(err)
+		}
+	})
+}
+
+// ApplyNetworkPref points the scrapers to the endpoints of the network preference
+// and routes every outbound request through its proxy.
+func ApplyNetworkPref() error {
+	p := prefs.Network
+	if err := utils.SetProxy(p.Proxy); err != nil {
+		return err
+	}
+
+	mangadex.SetBaseURL(p.Endpoints.MangaDexURL())
+	mangadex.SetImagesURL(p.Endpoints.ImagesURL())
+	mangadex.SetReportURL(p.Endpoints.ReportsURL())
+	anilist.SetBaseURL(p.Endpoints.AniListURL())
+	utils.SetUpstream("mangadex", p.Endpoints.MangaDexURL(), p.Endpoints.ImagesURL())
+	utils.SetUpstream("anilist", p.Endpoints.AniListURL())
+	return nil
+}
+
+// validateNetworkPref ensures the endpoints and the proxy are usable URLs
+func validateNetworkPref(p *prefs.NetworkPreference) error {
+	for _, v := range []string{p.Endpoints.MangaDex, p.Endpoints.Images, p.Endpoints.AniList, p.Endpoints.Reports} {
+		if len(v) == 0 {
+			continue
+		}
+		u, err := url.Parse(v)
+		if err != nil || len(u.Host) == 0 || (u.Scheme != "http" && u.Scheme != "https") {
+			return ErrInvalidEndpoint
+		}
+	}
+
+	if len(p.Proxy) > 0 {
+		if _, err := utils.ParseProxy(p.Proxy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func broadcastNetworkState() {
+	websocket.Broadcast <- &websocket.OutgoingMessage{
+		Task: Tasks.GetNetworkState,
+		Body: GetNetworkState(),
+	}
+}
+
+// enqueue postpones the task until MangaDex is reachable
+func enqueue(task Task, body string) error {
+	if err := queue.Push(task, body); err != nil {
+		return err
+	}
+	go broadcastNetworkState()
+	return ErrQueued
+}
+
+// processQueue runs the queued tasks and broadcasts their results,
+// the tasks which are left or aborted by the shutdown stay queued for the next start.
+func processQueue() {
+	if !beginWork() {
+		return
+	}
+	defer endWork()
+
+	queueMutex.Lock()
+	defer queueMutex.Unlock()
+
+	ctx := lifetime.ctx
+	for _, entry := range queue.All() {
+		if !IsOnline() || isStopping() {
+			break
+		}
+
+		var res any
+		var err error
+
+		switch entry.Task {
+		case Tasks.UpdateManga:
+			res, err = UpdateManga(ctx, entry.Body, false)
+		case Tasks.UpdateChapter:
+			res, err = UpdateChapter(ctx, entry.Body)
+		case Tasks.UpdateChapters:
+			res, err = UpdateChapters(ctx, entry.Body, false)
+		case Tasks.UpdateLibrary:
+			res, err = UpdateLibrary()
+		}
+
+		if err == ErrQueued || errors.Is(err, context.Canceled) {
+			break
+		}
+
+		if err != nil {
+			logger.Err.Println(entry.Task, entry.Body, err)
+		} else if res != nil {
+			websocket.Broadcast <- &websocket.OutgoingMessage{Task: entry.Task, Body: res}
+		}
+
+		if err := entry.Delete(); err != nil {
+			logger.Err.Println(err)
+		}
+	}
+
+	broadcastNetworkState()
+}
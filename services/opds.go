@@ -0,0 +1,286 @@
+package services
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	. "nonbiri/constants"
+
+	"nonbiri/models/chapter"
+	"nonbiri/models/manga"
+	"nonbiri/utils/opds"
+
+	"github.com/rs1703/logger"
+)
+
+type OPDSQuery struct {
+	Token string
+
+	// Scheme and host the links of the catalog point to
+	BaseURL string
+	// Path of the catalog root, /opds/v1 or /opds/v2
+	Root string
+}
+
+// Number of manga listed by the recently updated catalog
+const recentOPDSLimit = 50
+
+// link returns the absolute URL of the path with the token appended
+func (q *OPDSQuery) link(path string, params ...string) string {
+	v := url.Values{}
+	v.Set("token", q.Token)
+	for i := 0; i+1 < len(params); i += 2 {
+		v.Set(params[i], params[i+1])
+	}
+	return q.BaseURL + path + "?" + v.Encode()
+}
+
+func (q *OPDSQuery) feed(id, title, path string) *opds.Feed {
+	return &opds.Feed{
+		ID:      "urn:nonbiri:" + id,
+		Title:   title,
+		Self:    q.link(q.Root + path),
+		Start:   q.link(q.Root),
+		Updated: time.Now(),
+		Search:  q.link(q.Root + "/opensearch"),
+	}
+}
+
+func (q *OPDSQuery) mangaEntry(m *manga.Manga) *opds.Entry {
+	e := &opds.Entry{
+		ID:          "urn:mangadex:manga:" + m.ID,
+		Title:       m.Title,
+		Href:        q.link(q.Root + "/manga/" + m.ID),
+		Summary:     m.Description,
+		Updated:     time.Unix(int64(m.LatestChapterAt), 0),
+		Acquisition: true,
+	}
+	if len(m.Cover) > 0 {
+		e.Image = fmt.Sprintf("%s/0/covers/%s/%s", q.BaseURL, m.ID, m.Cover)
+		e.Thumbnail = e.Image + ".256.jpg"
+	}
+	return e
+}
+
+// OPDSCatalog returns the root of the catalog
+func OPDSCatalog(q OPDSQuery) (*opds.Feed, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(q.Token); err != nil {
+		return nil, err
+	}
+
+	f := q.feed("catalog", "Nonbiri", "")
+	for _, v := range [][]string{
+		{"library", "Library", "Followed manga"},
+		{"recent", "Recently Updated", "Followed manga with new chapters"},
+		{"downloaded", "Downloaded", "Manga with downloaded chapters"},
+	} {
+		f.Entries = append(f.Entries, &opds.Entry{
+			ID:      "urn:nonbiri:" + v[0],
+			Title:   v[1],
+			Href:    q.link(q.Root + "/" + v[0]),
+			Summary: v[2],
+			Updated: f.Updated,
+		})
+	}
+	return f, nil
+}
+
+// OPDSLibrary lists the followed manga by title
+func OPDSLibrary(q OPDSQuery) (*opds.Feed, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(q.Token); err != nil {
+		return nil, err
+	}
+
+	entries := append(manga.Slice{}, Library(false)...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Title) < strings.ToLower(entries[j].Title)
+	})
+
+	f := q.feed("library", "Library", "/library")
+	for _, m := range entries {
+		f.Entries = append(f.Entries, q.mangaEntry(m))
+	}
+	return f, nil
+}
+
+// OPDSRecent lists the followed manga by their latest chapter
+func OPDSRecent(q OPDSQuery) (*opds.Feed, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(q.Token); err != nil {
+		return nil, err
+	}
+
+	f := q.feed("recent", "Recently Updated", "/recent")
+	for _, m := range Library(false) {
+		if m.LatestChapterAt == 0 {
+			continue
+		}
+		f.Entries = append(f.Entries, q.mangaEntry(m))
+		if len(f.Entries) == recentOPDSLimit {
+			break
+		}
+	}
+	return f, nil
+}
+
+// OPDSDownloaded lists the manga which have downloaded chapters
+func OPDSDownloaded(q OPDSQuery) (*opds.Feed, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(q.Token); err != nil {
+		return nil, err
+	}
+
+	f := q.feed("downloaded", "Downloaded", "/downloaded")
+	seen := make(map[string]bool)
+	for _, c := range chapter.Downloaded() {
+		if seen[c.MangaId] {
+			continue
+		}
+		seen[c.MangaId] = true
+
+		m, err := manga.One(c.MangaId, false)
+		if err != nil {
+			logger.Err.Println(c.MangaId, err)
+			continue
+		}
+		f.Entries = append(f.Entries, q.mangaEntry(m))
+	}
+
+	sort.SliceStable(f.Entries, func(i, j int) bool {
+		return strings.ToLower(f.Entries[i].Title) < strings.ToLower(f.Entries[j].Title)
+	})
+	return f, nil
+}
+
+// OPDSSearch lists the followed manga matching the text
+func OPDSSearch(q OPDSQuery, text string) (*opds.Feed, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(q.Token); err != nil {
+		return nil, err
+	}
+
+	matches, err := manga.Search(text, true, 50, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	f := q.feed("search", "Search: "+text, "/search")
+	for _, m := range matches {
+		f.Entries = append(f.Entries, q.mangaEntry(&m.Manga))
+	}
+	return f, nil
+}
+
+// OPDSManga lists the downloaded chapters of the manga as CBZ files
+func OPDSManga(q OPDSQuery, id string) (*opds.Feed, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(q.Token); err != nil {
+		return nil, err
+	}
+
+	m, err := manga.One(id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters chapter.Slice
+	for _, c := range chapter.Downloaded() {
+		if c.MangaId == id {
+			chapters = append(chapters, c)
+		}
+	}
+	chapters.SortByChapter()
+
+	entry := q.mangaEntry(m)
+	f := q.feed("manga:"+id, m.Title, "/manga/"+id)
+	for _, c := range chapters {
+		f.Publications = append(f.Publications, &opds.Publication{
+			ID:        "urn:mangadex:chapter:" + c.ID,
+			Title:     chapterTitle(c),
+			Author:    groupNames(c),
+			Updated:   time.Unix(c.PublishAt, 0),
+			Href:      q.link("/opds/cbz/" + c.ID),
+			Type:      opds.CBZType,
+			Image:     entry.Image,
+			Thumbnail: entry.Thumbnail,
+		})
+	}
+	return f, nil
+}
+
+// CBZ is a downloaded chapter archived on the fly
+type CBZ struct {
+	Name  string
+	paths []string
+}
+
+// OpenCBZ prepares the archive of the downloaded chapter
+func OpenCBZ(token, id string) (*CBZ, error) {
+	defer logger.Track()()
+
+	if err := checkFeedToken(token); err != nil {
+		return nil, err
+	}
+
+	c, err := chapter.One(id)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, ok := c.PagePaths()
+	if !ok {
+		return nil, ErrChapterNotDownloaded
+	}
+
+	title := chapterTitle(c)
+	if m, err := manga.One(c.MangaId, false); err == nil {
+		title = m.Title + " " + title
+	}
+	return &CBZ{Name: sanitizeFileName(title) + ".cbz", paths: paths}, nil
+}
+
+// WriteArchive streams the pages without compression, they are compressed already
+func (a *CBZ) WriteArchive(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	for i, path := range a.paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%03d%s", i+1, filepath.Ext(path))
+		dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+		if err == nil {
+			_, err = io.Copy(dst, f)
+		}
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+}
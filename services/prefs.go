@@ -1,6 +1,7 @@
 package services
 
 import (
+	. "nonbiri/constants"
 	"nonbiri/prefs"
 
 	"github.com/rs1703/logger"
@@ -10,6 +11,10 @@ type Prefs struct {
 	Browse  *prefs.BrowsePreference  `json:"browse"`
 	Library *prefs.LibraryPreference `json:"library"`
 	Reader  *prefs.ReaderPreference  `json:"reader"`
+	Network *prefs.NetworkPreference `json:"network"`
+	Feed    *prefs.FeedPreference    `json:"feed"`
+	Email   *prefs.EmailPreference   `json:"email"`
+	Groups  *prefs.GroupPreference   `json:"groups"`
 }
 
 func GetPrefs() *Prefs {
@@ -19,10 +24,19 @@ func GetPrefs() *Prefs {
 		prefs.Browse,
 		prefs.Library,
 		prefs.Reader,
+		prefs.Network,
+		prefs.Feed,
+		prefs.Email,
+		prefs.Groups,
 	}
 }
 
 func UpdateBrowsePref(new *prefs.BrowsePreference) (*prefs.BrowsePreference, error) {
+	for _, language := range new.Languages {
+		if !language.IsValid() {
+			return nil, ErrInvalidLanguage
+		}
+	}
 	prefs.Browse.Update(new)
 	return prefs.Browse, nil
 }
@@ -31,14 +45,51 @@ func UpdateLibraryPref(new *prefs.LibraryPreference) (*prefs.LibraryPreference,
 	updateSchedule := new.UpdateFrequency != prefs.Library.UpdateFrequency
 	defer func() {
 		if updateSchedule {
-			go ScheduleUpdate()
+			go func() {
+				rescheduleLibrary()
+				ScheduleUpdate()
+			}()
 		}
 	}()
 	prefs.Library.Update(new)
 	return prefs.Library, nil
 }
 
+// UpdateGroupPref stores the preference, the updates are cached again
+// so the chapters of newly blocked groups disappear from them.
+func UpdateGroupPref(new *prefs.GroupPreference) (*prefs.GroupPreference, error) {
+	prefs.Groups.Update(new)
+	cacheUpdates(false)
+	return prefs.Groups, nil
+}
+
 func UpdateReaderPref(new *prefs.ReaderPreference) (*prefs.ReaderPreference, error) {
 	prefs.Reader.Update(new)
 	return prefs.Reader, nil
 }
+
+// UpdateNetworkPref stores the preference, the connection is checked again
+// when the endpoints or the proxy have changed.
+func UpdateNetworkPref(new *prefs.NetworkPreference) (*prefs.NetworkPreference, error) {
+	if err := validateNetworkPref(new); err != nil {
+		return nil, err
+	}
+
+	isGoingOnline := prefs.Network.Offline && !new.Offline
+	isRerouted := prefs.Network.Endpoints != new.Endpoints || prefs.Network.Proxy != new.Proxy
+	prefs.Network.Update(new)
+
+	if err := ApplyNetworkPref(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		broadcastNetworkState()
+		if isGoingOnline && CheckNetwork() {
+			processQueue()
+		} else if isRerouted && !new.Offline {
+			CheckNetwork()
+		}
+	}()
+	return prefs.Network, nil
+}
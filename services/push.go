@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	. "nonbiri/constants"
+
+	"nonbiri/models/chapter"
+	"nonbiri/models/manga"
+	"nonbiri/models/push"
+	"nonbiri/prefs"
+	"nonbiri/utils/webpush"
+
+	"github.com/rs1703/logger"
+)
+
+// Notification is the payload shown by the service worker
+type Notification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Icon  string `json:"icon,omitempty"`
+	// Notifications with the same tag replace each other
+	Tag string `json:"tag"`
+	// Opened when the notification is clicked, relative to the origin of the app
+	URL string `json:"url"`
+}
+
+// Seconds a notification is kept by the push service while the browser is offline
+const pushTTL = 24 * 60 * 60
+
+// GetPushKey returns the key browsers subscribe with, generated on the first use
+func GetPushKey() (string, error) {
+	defer logger.Track()()
+
+	if len(prefs.Push.Keys.Private) == 0 {
+		keys, err := webpush.GenerateKeys()
+		if err != nil {
+			return "", err
+		}
+
+		new := *prefs.Push
+		new.Keys = *keys
+		prefs.Push.Update(&new)
+	}
+	return prefs.Push.Keys.Public, nil
+}
+
+func GetPushSubscriptions() push.Slice {
+	defer logger.Track()()
+	return push.All()
+}
+
+// SubscribePush registers the browser, or replaces its filters when it is already registered
+func SubscribePush(s *push.Subscription) (*push.Subscription, error) {
+	defer logger.Track()()
+
+	if len(s.Endpoint) == 0 || len(s.P256dh) == 0 || len(s.Auth) == 0 {
+		return nil, ErrInvalidSubscription
+	}
+	if err := s.Save(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func UnsubscribePush(endpoint string) error {
+	defer logger.Track()()
+	return push.Delete(endpoint)
+}
+
+// notifyChapters sends a notification per manga to the matching subscriptions
+func notifyChapters(added chapter.Slice) {
+	added = withoutBlocked(added)
+	if len(added) == 0 || len(prefs.Push.Keys.Private) == 0 {
+		return
+	}
+
+	subs := push.All()
+	if len(subs) == 0 {
+		return
+	}
+
+	byManga := make(map[string]chapter.Slice)
+	for _, c := range added {
+		byManga[c.MangaId] = append(byManga[c.MangaId], c)
+	}
+
+	for id, chapters := range byManga {
+		m, err := manga.One(id, false)
+		if err != nil {
+			logger.Err.Println(id, err)
+			continue
+		}
+		if !m.Followed {
+			continue
+		}
+
+		payload, err := json.Marshal(newNotification(m, chapters))
+		if err != nil {
+			logger.Err.Println(err)
+			continue
+		}
+
+		for _, s := range subs {
+			if s.Matches(m.ID, m.FollowState) {
+				sendPush(s, payload, m.ID)
+			}
+		}
+	}
+}
+
+// newNotification links to the earliest of the new chapters
+func newNotification(m *manga.Manga, chapters chapter.Slice) *Notification {
+	chapters.SortByChapter()
+	latest, earliest := chapters[0], chapters[len(chapters)-1]
+
+	n := &Notification{
+		Title: m.Title,
+		Body:  chapterTitle(latest),
+		Tag:   m.ID,
+		URL:   fmt.Sprintf("/read/%s/%s", m.ID, earliest.ID),
+	}
+	if len(chapters) > 1 {
+		n.Body = fmt.Sprintf("%d new chapters, up to %s", len(chapters), n.Body)
+	}
+	if len(m.Cover) > 0 {
+		n.Icon = fmt.Sprintf("/0/covers/%s/%s.256.jpg", m.ID, m.Cover)
+	}
+	return n
+}
+
+// sendPush delivers the payload, expired subscriptions are removed
+func sendPush(s *push.Subscription, payload []byte, topic string) {
+	if !beginWork() {
+		return
+	}
+	defer endWork()
+
+	ctx, cancel := withLifetime(context.Background())
+	defer cancel()
+
+	sub := &webpush.Subscription{Endpoint: s.Endpoint, P256dh: s.P256dh, Auth: s.Auth}
+	opts := webpush.Options{Subject: prefs.Push.Subject, TTL: pushTTL, Topic: topic}
+
+	err := webpush.Send(ctx, sub, payload, &prefs.Push.Keys, opts)
+	if err == webpush.ErrGone {
+		if err := push.Delete(s.Endpoint); err != nil {
+			logger.Err.Println(err)
+		}
+	} else if err != nil {
+		logger.Err.Println(s.Endpoint, err)
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"nonbiri/models/manga"
+	"nonbiri/models/update"
+
+	"nonbiri/prefs"
+
+	"github.com/rs1703/logger"
+)
+
+var scheduler = struct {
+	Ticker *time.Ticker
+	Done   chan bool
+	sync.Mutex
+}{
+	Done: make(chan bool),
+}
+
+// How often the scheduler looks for schedules and manga which are due
+const scheduleCheckFrequency = time.Minute
+
+// ScheduleUpdate (re)starts the scheduler, it stops once the shutdown has started
+func ScheduleUpdate() {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+
+	if isStopping() {
+		return
+	}
+	if scheduler.Ticker != nil {
+		scheduler.Done <- true
+	}
+
+	runSchedules()
+
+	scheduler.Ticker = time.NewTicker(scheduleCheckFrequency)
+	go func() {
+		for {
+			select {
+			case <-scheduler.Done:
+				scheduler.Ticker.Stop()
+				return
+			case <-lifetime.stopping.Done():
+				scheduler.Ticker.Stop()
+				return
+			case <-scheduler.Ticker.C:
+				runSchedules()
+			}
+		}
+	}()
+}
+
+// isQuiet reports whether scheduled work should be held back
+func isQuiet() bool {
+	return prefs.Library.QuietHours.Contains(time.Now())
+}
+
+// runSchedules sends the email digest when it is due and starts the first schedule which is due,
+// otherwise updates the manga whose next check has passed.
+// The last run of every schedule is stored before it starts,
+// so a restart never fires the same occurrence twice.
+func runSchedules() {
+	if isQuiet() {
+		return
+	}
+	if beginWork() {
+		go func() {
+			defer endWork()
+			runDigest()
+		}()
+	}
+
+	if isUpdating() || !IsOnline() {
+		return
+	}
+
+	now := time.Now()
+	var excluded []FollowState
+	adaptive := true
+
+	for _, s := range prefs.Library.Schedules {
+		if s.Kind != UpdateKinds.Metadata {
+			if len(s.FollowStates) == 0 {
+				adaptive = false
+			}
+			excluded = append(excluded, s.FollowStates...)
+		}
+
+		key := s.Key()
+		lastRun, err := update.LastRun(key)
+		if err != nil {
+			logger.Err.Println(err)
+			continue
+		}
+
+		if lastRun == 0 {
+			if err := update.SetLastRun(key, now.Unix()); err != nil {
+				logger.Err.Println(err)
+			}
+			continue
+		}
+
+		next, err := s.Next(time.Unix(lastRun, 0))
+		if err != nil {
+			logger.Err.Println(key, err)
+			continue
+		}
+		if next.After(now) {
+			continue
+		}
+
+		if err := update.SetLastRun(key, now.Unix()); err != nil {
+			logger.Err.Println(err)
+			continue
+		}
+
+		follows, err := manga.Scheduled(s.FollowStates)
+		if err != nil {
+			logger.Err.Println(err)
+			continue
+		}
+		if len(follows) == 0 {
+			continue
+		}
+
+		if _, err := startUpdate(follows, true, s.Kind); err != nil {
+			logger.Err.Println(err)
+		}
+		return
+	}
+
+	if adaptive {
+		updateDue(now, excluded)
+	}
+}
+
+// updateDue updates the manga whose next check has passed,
+// the ones with an excluded follow state are updated by their own schedule.
+func updateDue(now time.Time, excluded []FollowState) {
+	follows, err := manga.Due(now, excluded)
+	if err != nil {
+		logger.Err.Println(err)
+		return
+	}
+	if len(follows) == 0 {
+		return
+	}
+
+	if _, err := startUpdate(follows, true, UpdateKinds.Chapters); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// reschedule computes the next check of the specified manga
+func reschedule(ids ...string) {
+	base := prefs.Library.UpdateFrequency * time.Hour
+	if err := manga.Reschedule(base, ids...); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// backoff pushes back the next check of the manga which failed to update
+func backoff(entries manga.Slice, err error) {
+	ids := make([]string, len(entries))
+	for i, m := range entries {
+		ids[i] = m.ID
+	}
+
+	base := prefs.Library.UpdateFrequency * time.Hour
+	if err := manga.Backoff(base, errors.Is(err, ErrMangaNotFound), ids...); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// rescheduleLibrary computes the next check of every followed manga
+func rescheduleLibrary() {
+	var ids []string
+	if err := DB.Select(&ids, "SELECT id FROM manga WHERE followed = true"); err != nil {
+		logger.Err.Println(err)
+		return
+	}
+	reschedule(ids...)
+}
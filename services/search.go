@@ -0,0 +1,35 @@
+package services
+
+import (
+	"nonbiri/models/manga"
+
+	"github.com/rs1703/logger"
+)
+
+type SearchData struct {
+	Entries manga.Matches `json:"entries"`
+	Query   SearchQuery   `json:"query"`
+}
+
+type SearchQuery struct {
+	Limit    int    `json:"limit,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	Text     string `json:"text"`
+	Followed bool   `json:"followed,omitempty"`
+}
+
+// Search searches the local manga, it works without a connection to MangaDex
+func Search(q SearchQuery) (*SearchData, error) {
+	defer logger.Track()()
+
+	if q.Limit == 0 {
+		q.Limit = 36
+	}
+
+	data, err := manga.Search(q.Text, q.Followed, q.Limit, q.Offset)
+	if err != nil {
+		logger.Err.Println(err)
+		return nil, err
+	}
+	return &SearchData{Entries: data, Query: q}, nil
+}
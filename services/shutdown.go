@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// How long aborted work is given to record where it stopped, taken from the end of the timeout
+const abortGrace = 5 * time.Second
+
+// Background work is no longer started once stopping is done,
+// the work which is still running is aborted once ctx is done.
+var lifetime = struct {
+	stopping context.Context
+	stop     context.CancelFunc
+	ctx      context.Context
+	abort    context.CancelFunc
+	work     sync.WaitGroup
+	sync.Mutex
+}{}
+
+func init() {
+	lifetime.stopping, lifetime.stop = context.WithCancel(context.Background())
+	lifetime.ctx, lifetime.abort = context.WithCancel(context.Background())
+}
+
+// beginWork registers work which Shutdown waits for,
+// returns false once the shutdown has started.
+func beginWork() bool {
+	lifetime.Lock()
+	defer lifetime.Unlock()
+
+	if lifetime.stopping.Err() != nil {
+		return false
+	}
+	lifetime.work.Add(1)
+	return true
+}
+
+func endWork() {
+	lifetime.work.Done()
+}
+
+// isStopping reports whether the shutdown has started
+func isStopping() bool {
+	return lifetime.stopping.Err() != nil
+}
+
+// withLifetime returns a copy of ctx which is also cancelled once the running work is aborted
+func withLifetime(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-lifetime.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Shutdown stops the schedulers and waits for the running updates, notifications and page downloads to finish.
+// They are cancelled abortGrace before the deadline of ctx, at most half of the timeout,
+// so that the manga an update has not reached are recorded as cancelled before ctx is done.
+func Shutdown(ctx context.Context) error {
+	lifetime.Lock()
+	lifetime.stop()
+	lifetime.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		lifetime.work.Wait()
+		close(done)
+	}()
+
+	abortCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		grace := abortGrace
+		if half := time.Until(deadline) / 2; half < grace {
+			grace = half
+		}
+		var cancel context.CancelFunc
+		abortCtx, cancel = context.WithDeadline(ctx, deadline.Add(-grace))
+		defer cancel()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-abortCtx.Done():
+	}
+
+	lifetime.abort()
+	select {
+	case <-done:
+		return abortCtx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
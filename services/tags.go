@@ -1,23 +1,133 @@
 package services
 
 import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	. "nonbiri/constants"
+
+	"nonbiri/models/manga"
 	"nonbiri/models/tag"
+	"nonbiri/scrapers/mangadex"
 	"nonbiri/utils"
-	"sort"
 
 	"github.com/rs1703/logger"
 )
 
-var tCache tag.Slice
+type TagData struct {
+	*tag.Tag
+	Entries manga.Slice `json:"entries"`
+}
+
+type TagGroup struct {
+	Name string    `json:"name"`
+	Tags tag.Slice `json:"tags"`
+}
+
+type TagGroups []*TagGroup
+
+// Order of the tag groups from MangaDex, unknown groups are placed last
+var tagGroupOrder = map[string]int{"genre": 1, "theme": 2, "format": 3, "content": 4}
+
+const tagRefreshFrequency = 24 * time.Hour
 
-func Tags() tag.Slice {
+var tCache TagGroups
+var tMutex = sync.Mutex{}
+
+func Tags() TagGroups {
 	defer logger.Track()()
 
+	tMutex.Lock()
+	defer tMutex.Unlock()
+
 	if len(tCache) == 0 {
-		tCache = tag.All()
-		sort.SliceStable(tCache, func(i, j int) bool {
-			return utils.ASC(tCache[i].Name, tCache[j].Name)
-		})
+		tCache = groupTags(tag.All())
 	}
 	return tCache
 }
+
+func groupTags(tags tag.Slice) (result TagGroups) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		return utils.ASC(tags[i].Name, tags[j].Name)
+	})
+
+	groups := make(map[string]*TagGroup)
+	for _, t := range tags {
+		group, exists := groups[t.Group]
+		if !exists {
+			group = &TagGroup{Name: t.Group}
+			groups[t.Group] = group
+			result = append(result, group)
+		}
+		group.Tags = append(group.Tags, t)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := tagGroupOrder[result[i].Name], tagGroupOrder[result[j].Name]
+		if a == 0 || b == 0 {
+			return a > b
+		}
+		return a < b
+	})
+	return
+}
+
+// RefreshTags retrieves tags from MangaDex and stores them
+func RefreshTags(ctx context.Context) error {
+	defer logger.Track()()
+
+	if !IsOnline() {
+		return ErrOffline
+	}
+
+	tags, err := mangadex.TagsEx(ctx)
+	if err != nil {
+		handleNetworkError(err)
+		return err
+	}
+
+	for _, t := range tags {
+		if _, err := t.Save(); err != nil {
+			return err
+		}
+	}
+
+	tMutex.Lock()
+	tCache = nil
+	tMutex.Unlock()
+	return nil
+}
+
+// ScheduleTagRefresh refreshes tags once per tagRefreshFrequency until the shutdown has started
+func ScheduleTagRefresh() {
+	ticker := time.NewTicker(tagRefreshFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lifetime.stopping.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !IsOnline() || isQuiet() {
+			continue
+		}
+		if err := RefreshTags(lifetime.stopping); err != nil {
+			logger.Err.Println(err)
+		}
+	}
+}
+
+// GetTag retrieves a tag by its id or name and every known manga with the tag
+func GetTag(v string) (*TagData, error) {
+	defer logger.Track()()
+
+	data, err := tag.Resolve(v)
+	if err != nil {
+		return nil, err
+	}
+	return &TagData{Tag: data, Entries: manga.ByTag(data.ID)}, nil
+}
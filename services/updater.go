@@ -0,0 +1,664 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	. "nonbiri/constants"
+	. "nonbiri/database"
+
+	"nonbiri/models/chapter"
+	"nonbiri/models/manga"
+	"nonbiri/models/update"
+	"nonbiri/prefs"
+	"nonbiri/utils/metrics"
+	"nonbiri/websocket"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs1703/logger"
+)
+
+// Number of manga whose chapters are requested at once by incremental updates
+const updateBatchSize = 100
+
+// Number of runs kept in the update history
+const keptUpdateRuns = 100
+
+// How long discovered chapters are kept when no digest has read them
+const keptDiscoveredAge = 30 * 24 * time.Hour
+
+var (
+	updateDuration = metrics.NewHistogram("nonbiri_update_duration_seconds", "Duration of the update runs by kind.",
+		[]float64{10, 30, 60, 120, 300, 600, 1800, 3600, 7200}, "kind")
+	updateNewChapters = metrics.NewCounter("nonbiri_update_new_chapters_total", "Chapters found by the update runs by kind.", "kind")
+)
+
+// Transient failures are retried with a delay doubling from updateRetryDelay
+const (
+	updateAttempts   = 3
+	updateRetryDelay = 2 * time.Second
+)
+
+type MangaUpdate struct {
+	ID          string       `json:"id"`
+	Title       string       `json:"title"`
+	Status      UpdateStatus `json:"status"`
+	NewChapters int          `json:"newChapters"`
+	Attempts    int          `json:"attempts,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Failure     Failure      `json:"failure,omitempty"`
+}
+
+type UpdateState struct {
+	Progress    int        `json:"progress"`
+	Total       int        `json:"total"`
+	Current     string     `json:"current"`
+	NewChapters int        `json:"newChapters"`
+	Failed      int        `json:"failed"`
+	Paused      bool       `json:"paused"`
+	RunID       int64      `json:"runId"`
+	Kind        UpdateKind `json:"kind"`
+	StartedAt   int64      `json:"startedAt"`
+	ETA         int64      `json:"eta"` // Estimated seconds left
+
+	Entries []*MangaUpdate `json:"entries"`
+}
+
+type updateJob struct {
+	entries manga.Slice
+	kind    UpdateKind
+	batch   bool
+}
+
+type libraryUpdater struct {
+	run     *update.Run
+	state   *UpdateState
+	entries map[string]*MangaUpdate
+	cancel  context.CancelFunc
+	resume  chan struct{} // Closed when the paused update resumes
+
+	elapsed   time.Duration // Time spent running before the last pause
+	resumedAt time.Time
+	sync.Mutex
+}
+
+var updater = &libraryUpdater{}
+
+// UpdateLibrary updates the metadata and chapters of every followed manga
+func UpdateLibrary() (*UpdateState, error) {
+	if isUpdating() {
+		return GetUpdateLibraryState(), nil
+	}
+
+	if !IsOnline() {
+		return nil, enqueue(Tasks.UpdateLibrary, "")
+	}
+
+	q := `SELECT id, title, lastChecked FROM manga
+				WHERE manga.followed = TRUE`
+
+	follows := manga.Slice{}
+	if err := DB.Select(&follows, q); err != nil {
+		logger.Err.Println(err)
+		return nil, err
+	}
+	return startUpdate(follows, false, UpdateKinds.All)
+}
+
+// startUpdate records a new run and updates the manga in the background
+func startUpdate(follows manga.Slice, scheduled bool, kind UpdateKind) (*UpdateState, error) {
+	if !beginWork() {
+		return nil, ErrShuttingDown
+	}
+	ctx, cancel := context.WithCancel(lifetime.ctx)
+
+	updater.Lock()
+	if updater.state != nil {
+		updater.Unlock()
+		cancel()
+		endWork()
+		return GetUpdateLibraryState(), nil
+	}
+
+	run := &update.Run{StartedAt: time.Now().Unix(), Scheduled: scheduled, Kind: kind, Total: len(follows)}
+	if err := update.Start(run); err != nil {
+		updater.Unlock()
+		cancel()
+		endWork()
+		return nil, err
+	}
+
+	updater.run = run
+	updater.state = &UpdateState{Total: len(follows), RunID: run.ID, Kind: kind, StartedAt: run.StartedAt}
+	updater.entries = make(map[string]*MangaUpdate)
+	for _, m := range follows {
+		entry := &MangaUpdate{ID: m.ID, Title: m.Title}
+		updater.state.Entries = append(updater.state.Entries, entry)
+		updater.entries[m.ID] = entry
+	}
+	updater.cancel = cancel
+	updater.resume = nil
+	updater.elapsed = 0
+	updater.resumedAt = time.Now()
+	updater.Unlock()
+
+	prefs.Library.LastUpdated = time.Now().Unix()
+	prefs.Library.Update(nil)
+
+	go func() {
+		defer endWork()
+		runUpdate(ctx, follows, kind)
+	}()
+	return GetUpdateLibraryState(), nil
+}
+
+func GetUpdateLibraryState() *UpdateState {
+	updater.Lock()
+	defer updater.Unlock()
+	return updater.snapshot()
+}
+
+// CancelUpdateLibrary stops the running update, manga which are being updated are finished first
+func CancelUpdateLibrary() (*UpdateState, error) {
+	updater.Lock()
+	defer updater.Unlock()
+
+	if updater.state == nil {
+		return nil, ErrNotUpdating
+	}
+	updater.cancel()
+	return updater.snapshot(), nil
+}
+
+// PauseUpdateLibrary holds the workers once they are done with their current manga
+func PauseUpdateLibrary() (*UpdateState, error) {
+	updater.Lock()
+	if updater.state == nil {
+		updater.Unlock()
+		return nil, ErrNotUpdating
+	}
+
+	if updater.resume == nil {
+		updater.resume = make(chan struct{})
+		updater.elapsed += time.Since(updater.resumedAt)
+		updater.state.Paused = true
+	}
+	updater.Unlock()
+
+	broadcastUpdateState()
+	return GetUpdateLibraryState(), nil
+}
+
+func ResumeUpdateLibrary() (*UpdateState, error) {
+	updater.Lock()
+	if updater.state == nil {
+		updater.Unlock()
+		return nil, ErrNotUpdating
+	}
+
+	if updater.resume != nil {
+		close(updater.resume)
+		updater.resume = nil
+		updater.resumedAt = time.Now()
+		updater.state.Paused = false
+	}
+	updater.Unlock()
+
+	broadcastUpdateState()
+	return GetUpdateLibraryState(), nil
+}
+
+type UpdateHistoryData struct {
+	Runs    update.Runs       `json:"runs"`
+	Failing []*update.Failing `json:"failing"`
+}
+
+func GetUpdateHistory() *UpdateHistoryData {
+	defer logger.Track()()
+	return &UpdateHistoryData{Runs: update.All(keptUpdateRuns), Failing: update.Failings()}
+}
+
+func GetUpdateRun(id int64) (*update.Run, error) {
+	defer logger.Track()()
+	return update.One(id)
+}
+
+// RetryFailedUpdates updates the manga which failed in the specified run,
+// or the ones which have been failing since they were last updated when runID is 0.
+func RetryFailedUpdates(runID int64) (*UpdateState, error) {
+	defer logger.Track()()
+
+	if !IsOnline() {
+		return nil, ErrOffline
+	}
+
+	var ids []string
+	if runID > 0 {
+		run, err := update.One(runID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range run.Entries {
+			if e.Status == UpdateStatuses.Failed {
+				ids = append(ids, e.MangaID)
+			}
+		}
+	} else {
+		for _, f := range update.Failings() {
+			ids = append(ids, f.MangaID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrNoFailedUpdates
+	}
+
+	q, args, err := sqlx.In(`SELECT id, title, lastChecked FROM manga
+		WHERE manga.followed = TRUE AND id IN (?)`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	follows := manga.Slice{}
+	if err := DB.Select(&follows, DB.Rebind(q), args...); err != nil {
+		return nil, err
+	}
+	if len(follows) == 0 {
+		return nil, ErrNoFailedUpdates
+	}
+	return startUpdate(follows, false, UpdateKinds.All)
+}
+
+func isUpdating() bool {
+	updater.Lock()
+	defer updater.Unlock()
+	return updater.state != nil
+}
+
+// runUpdate distributes the follows between the workers,
+// the workers share the rate limit of the MangaDex client.
+// No manga is started once the shutdown has started, the ones which are not reached are cancelled.
+func runUpdate(ctx context.Context, follows manga.Slice, kind UpdateKind) {
+	workers := prefs.Library.UpdateWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan updateJob)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				runUpdateJob(ctx, job)
+			}
+		}()
+	}
+
+feed:
+	for _, job := range splitUpdateJobs(follows, kind) {
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			break feed
+		case <-lifetime.stopping.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	updater.Lock()
+	run := updater.run
+	run.EndedAt = time.Now().Unix()
+	run.Cancelled = ctx.Err() != nil || isStopping()
+	run.Total = updater.state.Total
+	run.NewChapters = updater.state.NewChapters
+	run.Failed = updater.state.Failed
+
+	for _, entry := range updater.state.Entries {
+		if entry.Status == UpdateStatuses.Queued || entry.Status == UpdateStatuses.Updating {
+			entry.Status = UpdateStatuses.Cancelled
+			saveUpdateEntry(run.ID, entry)
+		}
+	}
+
+	updater.run = nil
+	updater.state = nil
+	updater.entries = nil
+	updater.cancel()
+	updater.Unlock()
+
+	if _, err := run.Finish(); err != nil {
+		logger.Err.Println(err)
+	}
+	updateDuration.Observe(float64(run.EndedAt-run.StartedAt), kind.String())
+	updateNewChapters.Add(float64(run.NewChapters), kind.String())
+	emit(Events.UpdateFinished, &WebhookPayload{Run: run})
+	if err := update.Prune(keptUpdateRuns); err != nil {
+		logger.Err.Println(err)
+	}
+	if err := pruneDiscovered(); err != nil {
+		logger.Err.Println(err)
+	}
+
+	websocket.Broadcast <- &websocket.OutgoingMessage{
+		Task: Tasks.GetUpdateLibraryState,
+	}
+
+	prefs.Library.LastUpdated = time.Now().Unix()
+	prefs.Library.Update(nil)
+
+	cacheLibrary(false)
+	cacheUpdates(false)
+
+	if !run.Cancelled && kind != UpdateKinds.Metadata && beginWork() {
+		go func() {
+			defer endWork()
+			if err := checkFollows(lifetime.ctx); err != nil {
+				logger.Err.Println(err)
+			}
+		}()
+	}
+}
+
+// splitUpdateJobs groups the manga which have been checked before into batches,
+// the others need their full feed and are updated one by one.
+// Metadata is always retrieved in batches.
+func splitUpdateJobs(follows manga.Slice, kind UpdateKind) (jobs []updateJob) {
+	var batched manga.Slice
+	for _, m := range follows {
+		if kind == UpdateKinds.Metadata || (prefs.Library.IncrementalUpdates && m.LastChecked > 0) {
+			batched = append(batched, m)
+		} else {
+			jobs = append(jobs, updateJob{entries: manga.Slice{m}, kind: kind})
+		}
+	}
+
+	for i := 0; i < len(batched); i += updateBatchSize {
+		end := i + updateBatchSize
+		if end > len(batched) {
+			end = len(batched)
+		}
+		jobs = append(jobs, updateJob{entries: batched[i:end], kind: kind, batch: true})
+	}
+	return
+}
+
+func runUpdateJob(ctx context.Context, job updateJob) {
+	// Chapters the batch has saved before it failed, by manga
+	committed := make(map[string]chapter.Slice)
+
+	if job.batch {
+		if !waitUpdateResume(ctx) {
+			return
+		}
+		setUpdateStatus(job.entries, UpdateStatuses.Updating)
+
+		// A failed attempt may have saved the chapters of some languages already,
+		// they are kept across the attempts and reported by the fallback.
+		var added chapter.Slice
+		var missing manga.Slice
+		err := retryUpdate(ctx, job.entries, func() error {
+			saved, notFound, err := updateMangaBatch(ctx, job.entries, job.kind)
+			added = append(added, saved...)
+			missing = notFound
+			return err
+		})
+		if err == nil {
+			finishUpdate(missing, nil, ErrMangaNotFound)
+			finishUpdate(excludeManga(job.entries, missing), added, nil)
+			return
+		}
+
+		// Falls back to updating them one by one
+		logger.Err.Println(err)
+		setUpdateStatus(job.entries, UpdateStatuses.Queued)
+		for _, c := range added {
+			committed[c.MangaId] = append(committed[c.MangaId], c)
+		}
+	}
+
+	for i, m := range job.entries {
+		if !waitUpdateResume(ctx) {
+			// The chapters the batch has saved are reported for the manga it does not reach
+			for _, m := range job.entries[i:] {
+				if len(committed[m.ID]) > 0 {
+					finishUpdate(manga.Slice{m}, committed[m.ID], context.Canceled)
+				}
+			}
+			return
+		}
+		setUpdateStatus(manga.Slice{m}, UpdateStatuses.Updating)
+
+		var added chapter.Slice
+		err := retryUpdate(ctx, manga.Slice{m}, func() (err error) {
+			switch job.kind {
+			case UpdateKinds.Chapters:
+				_, added, err = updateChapters(ctx, m.ID, true)
+			case UpdateKinds.Metadata:
+				_, err = updateMetadata(ctx, m.ID)
+			default:
+				_, added, err = updateManga(ctx, m.ID, true)
+			}
+			return
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			logger.Err.Println(m.ID, err)
+		}
+		finishUpdate(manga.Slice{m}, append(committed[m.ID], added...), err)
+	}
+}
+
+// retryUpdate runs fn until it succeeds, fails permanently or runs out of attempts
+func retryUpdate(ctx context.Context, entries manga.Slice, fn func() error) error {
+	delay := updateRetryDelay
+	for attempt := 1; ; attempt++ {
+		updater.Lock()
+		for _, m := range entries {
+			updater.entries[m.ID].Attempts = attempt
+		}
+		updater.Unlock()
+
+		err := fn()
+		if err == nil || !isTransient(err) || attempt == updateAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// A network error of any worker marks MangaDex as unreachable
+		if !IsOnline() && !prefs.Network.Offline {
+			CheckNetwork()
+		}
+	}
+}
+
+// isTransient reports whether the request may succeed when it is sent again
+func isTransient(err error) bool {
+	if err == ErrOffline {
+		return !prefs.Network.Offline
+	}
+
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &netErr) || errors.As(err, &syntaxErr)
+}
+
+// waitUpdateResume blocks while the update is paused,
+// returns false once the update has been cancelled or the shutdown has started.
+func waitUpdateResume(ctx context.Context) bool {
+	updater.Lock()
+	resume := updater.resume
+	updater.Unlock()
+
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-ctx.Done():
+		case <-lifetime.stopping.Done():
+		}
+	}
+	return ctx.Err() == nil && !isStopping()
+}
+
+func setUpdateStatus(entries manga.Slice, status UpdateStatus) {
+	updater.Lock()
+	for _, m := range entries {
+		updater.entries[m.ID].Status = status
+	}
+	if status == UpdateStatuses.Updating {
+		if len(entries) > 1 {
+			updater.state.Current = fmt.Sprintf("%d titles", len(entries))
+		} else {
+			updater.state.Current = entries[0].Title
+		}
+	}
+	updater.Unlock()
+
+	broadcastUpdateState()
+}
+
+func finishUpdate(entries manga.Slice, added chapter.Slice, err error) {
+	if len(entries) == 0 {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, c := range added {
+		counts[c.MangaId]++
+	}
+
+	updater.Lock()
+	for _, m := range entries {
+		entry := updater.entries[m.ID]
+		entry.NewChapters = counts[m.ID]
+		entry.Status = UpdateStatuses.Updated
+		if errors.Is(err, context.Canceled) {
+			entry.Status = UpdateStatuses.Cancelled
+		} else if err != nil {
+			entry.Status = UpdateStatuses.Failed
+			entry.Error = err.Error()
+			entry.Failure = failureOf(err)
+			updater.state.Failed++
+		}
+		updater.state.NewChapters += entry.NewChapters
+		updater.state.Progress++
+		saveUpdateEntry(updater.run.ID, entry)
+	}
+	runID := updater.run.ID
+	updater.Unlock()
+
+	saveDiscovered(runID, added)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		backoff(entries, err)
+	}
+
+	// The chapters have been saved even when the update failed afterwards
+	broadcastUpdateState()
+	if len(added) > 0 {
+		go notifyChapters(added)
+	}
+}
+
+func saveUpdateEntry(runID int64, entry *MangaUpdate) {
+	e := &update.Entry{
+		RunID:       runID,
+		MangaID:     entry.ID,
+		Title:       entry.Title,
+		Status:      entry.Status,
+		NewChapters: entry.NewChapters,
+		Attempts:    entry.Attempts,
+		Error:       entry.Error,
+		Failure:     entry.Failure,
+	}
+	if _, err := e.Save(nil); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// saveDiscovered records the new chapters for the email digest
+func saveDiscovered(runID int64, added chapter.Slice) {
+	ids := make([]string, len(added))
+	for i, c := range added {
+		ids[i] = c.ID
+	}
+	if err := update.AddChapters(runID, ids); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// failureOf tells why a manga could not be updated
+func failureOf(err error) Failure {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrMangaNotFound):
+		return Failures.NotFound
+	case errors.Is(err, ErrRateLimited):
+		return Failures.RateLimited
+	case errors.Is(err, ErrForbidden):
+		return Failures.Forbidden
+	case errors.Is(err, ErrOffline), errors.Is(err, ErrUpstreamUnavailable), errors.As(err, &netErr):
+		return Failures.Network
+	default:
+		return Failures.Other
+	}
+}
+
+func excludeManga(entries, excluded manga.Slice) (result manga.Slice) {
+	m := excluded.Map()
+	for _, entry := range entries {
+		if _, ok := m[entry.ID]; !ok {
+			result = append(result, entry)
+		}
+	}
+	return
+}
+
+func broadcastUpdateState() {
+	state := GetUpdateLibraryState()
+	if state == nil {
+		return
+	}
+	websocket.Broadcast <- &websocket.OutgoingMessage{
+		Task: Tasks.GetUpdateLibraryState,
+		Body: state,
+	}
+}
+
+// snapshot copies the state so it can be serialized while the workers keep running,
+// must be called with updater locked.
+func (u *libraryUpdater) snapshot() *UpdateState {
+	if u.state == nil {
+		return nil
+	}
+
+	state := *u.state
+	state.Entries = make([]*MangaUpdate, len(u.state.Entries))
+	for i, entry := range u.state.Entries {
+		e := *entry
+		state.Entries[i] = &e
+	}
+
+	elapsed := u.elapsed
+	if u.resume == nil {
+		elapsed += time.Since(u.resumedAt)
+	}
+	if state.Progress > 0 {
+		left := elapsed / time.Duration(state.Progress) * time.Duration(state.Total-state.Progress)
+		state.ETA = int64(left.Seconds())
+	}
+	return &state
+}
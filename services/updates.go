@@ -2,6 +2,7 @@ package services
 
 import (
 	"nonbiri/models/chapter"
+	"nonbiri/prefs"
 
 	"github.com/rs1703/logger"
 )
@@ -15,7 +16,7 @@ func Updates(isCaching bool) (_ chapter.Slice, err error) {
 	}
 
 	if len(uCache) == 0 {
-		uCache, err = chapter.All(360)
+		uCache, err = chapter.All(360, prefs.Groups.Blocked.Ids())
 	}
 
 	if track != nil {
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	. "nonbiri/constants"
+
+	"nonbiri/models/chapter"
+	"nonbiri/models/manga"
+	"nonbiri/models/update"
+	"nonbiri/models/webhook"
+
+	"github.com/rs1703/logger"
+)
+
+// WebhookPayload is the body of a delivery, the data of the webhook template
+type WebhookPayload struct {
+	Event     string `json:"event"`
+	Timestamp int64  `json:"timestamp"`
+
+	Manga   *manga.Manga     `json:"manga,omitempty"`
+	Chapter *chapter.Chapter `json:"chapter,omitempty"`
+	Run     *update.Run      `json:"run,omitempty"`
+
+	// Status of the manga before it changed
+	PreviousStatus Status `json:"previousStatus,omitempty"`
+}
+
+// Number of deliveries kept in the log of every webhook
+const keptWebhookDeliveries = 100
+
+// Number of deliveries posted at the same time
+const webhookConcurrency = 4
+
+var webhookSlots = make(chan struct{}, webhookConcurrency)
+
+func GetWebhooks() webhook.Slice {
+	defer logger.Track()()
+	return webhook.All()
+}
+
+// SaveWebhook creates the webhook, or updates it when it has an ID
+func SaveWebhook(in *webhook.Input) (*webhook.Webhook, error) {
+	defer logger.Track()()
+
+	w := &in.Webhook
+	if in.Secret != nil {
+		w.Secret = *in.Secret
+	} else if w.ID > 0 {
+		prev, err := webhook.One(w.ID)
+		if err != nil {
+			return nil, err
+		}
+		w.Secret = prev.Secret
+	}
+
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	if err := w.Save(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func DeleteWebhook(id int64) error {
+	defer logger.Track()()
+	return webhook.Delete(id)
+}
+
+// GetWebhookLog returns the latest deliveries of the webhook
+func GetWebhookLog(id int64) (webhook.Deliveries, error) {
+	defer logger.Track()()
+
+	if _, err := webhook.One(id); err != nil {
+		return nil, err
+	}
+	return webhook.Log(id, keptWebhookDeliveries), nil
+}
+
+// TestWebhook delivers a ping to the webhook and waits for the result
+func TestWebhook(id int64) (*webhook.Delivery, error) {
+	defer logger.Track()()
+
+	w, err := webhook.One(id)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := newDelivery(w, Events.Ping, newPayload(Events.Ping))
+	if err != nil {
+		return nil, err
+	}
+	deliver(w, d)
+	return d, nil
+}
+
+// ResumeWebhooks delivers the deliveries interrupted by the previous shutdown
+func ResumeWebhooks() {
+	for _, d := range webhook.Pending() {
+		w, err := webhook.One(d.WebhookID)
+		if err != nil {
+			logger.Err.Println(d.ID, err)
+			continue
+		}
+		go deliver(w, d)
+	}
+}
+
+func newPayload(event Event) *WebhookPayload {
+	return &WebhookPayload{Event: event.String(), Timestamp: time.Now().Unix()}
+}
+
+// emit delivers the event to the webhooks subscribed to it in the background
+func emit(event Event, payload *WebhookPayload) {
+	hooks := webhook.Subscribed(event)
+	if len(hooks) == 0 {
+		return
+	}
+
+	payload.Event = event.String()
+	payload.Timestamp = time.Now().Unix()
+
+	// The chapters of the manga are delivered by their own events
+	if payload.Manga != nil && len(payload.Manga.Chapters) > 0 {
+		m := *payload.Manga
+		m.Chapters = nil
+		payload.Manga = &m
+	}
+
+	for _, w := range hooks {
+		d, err := newDelivery(w, event, payload)
+		if err != nil {
+			logger.Err.Println(w.ID, err)
+			continue
+		}
+		go deliver(w, d)
+	}
+}
+
+// newDelivery renders and stores the payload before it is posted,
+// deliveries which could not be rendered are logged as failed.
+func newDelivery(w *webhook.Webhook, event Event, payload *WebhookPayload) (*webhook.Delivery, error) {
+	d := &webhook.Delivery{WebhookID: w.ID, Event: event, CreatedAt: time.Now().Unix()}
+
+	body, err := w.Render(payload)
+	if err != nil {
+		d.Status = DeliveryStatuses.Failed
+		d.Error = err.Error()
+	}
+	d.Body = string(body)
+
+	if err := d.Save(); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// deliver sends the delivery and stores its result,
+// one interrupted by the shutdown stays pending until ResumeWebhooks.
+func deliver(w *webhook.Webhook, d *webhook.Delivery) {
+	if !beginWork() {
+		return
+	}
+	defer endWork()
+
+	ctx, cancel := withLifetime(context.Background())
+	defer cancel()
+
+	select {
+	case webhookSlots <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-webhookSlots }()
+
+	if err := w.Deliver(ctx, d); err != nil {
+		logger.Err.Println(w.URL, err)
+	}
+	if err := d.Save(); err != nil {
+		logger.Err.Println(err)
+	}
+	if err := webhook.PruneDeliveries(w.ID, keptWebhookDeliveries); err != nil {
+		logger.Err.Println(err)
+	}
+}
+
+// emitChapters emits an event for every new chapter of the followed manga
+func emitChapters(added chapter.Slice) {
+	added = withoutBlocked(added)
+	if len(added) == 0 || len(webhook.Subscribed(Events.ChapterAdded)) == 0 {
+		return
+	}
+
+	cache := make(manga.Map)
+	for _, c := range added {
+		m, ok := cache[c.MangaId]
+		if !ok {
+			var err error
+			if m, err = manga.One(c.MangaId, false); err != nil {
+				logger.Err.Println(c.MangaId, err)
+				continue
+			}
+			cache[c.MangaId] = m
+		}
+
+		if m.Followed {
+			emit(Events.ChapterAdded, &WebhookPayload{Manga: m, Chapter: c})
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed 5-field cron expression:
+// minute, hour, day of month, month and day of week.
+//
+// Fields support *, lists (1,2), ranges (1-5) and steps (*/15, 0-30/10).
+// Day of week ranges from 0 (Sunday) to 6, 7 is accepted as Sunday as well.
+type Expression struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// Whether the day of month or the day of week field is restricted,
+	// a day matches either of them when both are.
+	anyDay     bool
+	anyWeekday bool
+}
+
+type bounds struct{ min, max int }
+
+var fieldBounds = []bounds{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+var ErrInvalidExpression = errors.New("invalid cron expression")
+
+func Parse(expr string) (*Expression, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidExpression, len(fields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseField(field, fieldBounds[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidExpression, field, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday is both 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &Expression{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}, nil
+}
+
+func parseField(field string, b bounds) (set uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, errors.New("invalid step")
+			}
+			part = part[:i]
+		}
+
+		lo, hi := b.min, b.max
+		if part != "*" {
+			if i := strings.IndexByte(part, '-'); i >= 0 {
+				if lo, err = strconv.Atoi(part[:i]); err != nil {
+					return 0, err
+				}
+				if hi, err = strconv.Atoi(part[i+1:]); err != nil {
+					return 0, err
+				}
+			} else {
+				if lo, err = strconv.Atoi(part); err != nil {
+					return 0, err
+				}
+				hi = lo
+				if step > 1 {
+					hi = b.max
+				}
+			}
+		}
+
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, errors.New("out of range")
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return
+}
+
+// Next returns the first time after t matching the expression
+func (e *Expression) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Every combination repeats within a few years
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if e.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !e.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if e.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if e.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (e *Expression) matchDay(t time.Time) bool {
+	day := e.days&(1<<uint(t.Day())) != 0
+	weekday := e.weekdays&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case e.anyDay && e.anyWeekday:
+		return true
+	case e.anyDay:
+		return weekday
+	case e.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
@@ -0,0 +1,46 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"nonbiri/utils/cron"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2022, time.March, 31, 22, 47, 30, 0, time.UTC) // Thursday
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2022, time.March, 31, 22, 48, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2022, time.March, 31, 23, 0, 0, 0, time.UTC)},
+		{"0 8,20 * * *", time.Date(2022, time.April, 1, 8, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2022, time.April, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2022, time.April, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2022, time.April, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 12 15 * *", time.Date(2022, time.April, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 6 1 * 6", time.Date(2022, time.April, 1, 6, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		e, err := cron.Parse(c.expr)
+		if err != nil {
+			t.Errorf("%q: %v", c.expr, err)
+			continue
+		}
+		if got := e.Next(from); !got.Equal(c.want) {
+			t.Errorf("%q: expected %v, got %v", c.expr, c.want, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := cron.Parse(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package feed encodes a list of entries as an Atom or RSS 2.0 feed
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type Feed struct {
+	ID      string
+	Title   string
+	Link    string
+	Updated time.Time
+	Items   []*Item
+}
+
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Author    string
+	Image     string
+	Published time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Links     []atomLink  `xml:"link"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Summary   string      `xml:"summary,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	GUID        rssGUID       `xml:"guid"`
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	Description string        `xml:"description,omitempty"`
+	Author      string        `xml:"author,omitempty"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosure   *rssEnclosure `xml:"enclosure,omitempty"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int    `xml:"length,attr"`
+}
+
+// Atom encodes the feed as an Atom document
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links:   []atomLink{{Href: f.Link}},
+	}
+
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Updated:   item.Published.UTC().Format(time.RFC3339),
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate"}},
+			Summary:   item.Summary,
+		}
+		if len(item.Image) > 0 {
+			entry.Links = append(entry.Links, atomLink{Href: item.Image, Rel: "enclosure", Type: "image/jpeg"})
+		}
+		if len(item.Author) > 0 {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return encode(doc)
+}
+
+// RSS encodes the feed as a RSS 2.0 document
+func (f *Feed) RSS() ([]byte, error) {
+	doc := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, item := range f.Items {
+		v := rssItem{
+			GUID:        rssGUID{Value: item.ID},
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Summary,
+			Author:      item.Author,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		}
+		if len(item.Image) > 0 {
+			v.Enclosure = &rssEnclosure{URL: item.Image, Type: "image/jpeg"}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, v)
+	}
+	return encode(doc)
+}
+
+func encode(doc any) ([]byte, error) {
+	buf, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), buf...), nil
+}
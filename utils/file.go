@@ -4,14 +4,33 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
 func ReadFile(filePath string) ([]byte, error) {
 	return ioutil.ReadFile(filePath)
 }
 
+// WriteFile writes to a temporary file which then replaces outputPath,
+// so an interrupted write never leaves a truncated file behind.
 func WriteFile(buf []byte, outputPath string) error {
-	return ioutil.WriteFile(outputPath, buf, 0644)
+	f, err := os.CreateTemp(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), outputPath)
 }
 
 func WriteReader(r io.Reader, outputPath string) error {
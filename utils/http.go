@@ -2,71 +2,415 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
+	"math/rand"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "nonbiri/constants"
+	"nonbiri/utils/metrics"
 
 	"github.com/rs1703/logger"
 )
 
 const userAgent = "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/40.0.2214.85 Safari/537.36"
 
-func Get(url string, header ...map[string]string) ([]byte, error) {
-	client := &http.Client{}
+// Transport carries every outbound request, through the proxy when one is set
+var Transport = &http.Transport{
+	Proxy: func(req *http.Request) (*url.URL, error) {
+		proxy.RLock()
+		defer proxy.RUnlock()
+		return proxy.fn(req)
+	},
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 20 * time.Second,
+}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+// Client is shared by the upstream requests so their connections are reused
+var Client = &http.Client{
+	Timeout:   30 * time.Second,
+	Transport: Transport,
+}
+
+var proxy = struct {
+	sync.RWMutex
+	fn func(*http.Request) (*url.URL, error)
+}{fn: http.ProxyFromEnvironment}
+
+// Proxy schemes supported by the transport
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// ParseProxy validates the URL of an HTTP, HTTPS or SOCKS5 proxy
+func ParseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || len(u.Host) == 0 || !Contains(proxySchemes, u.Scheme) {
+		return nil, ErrInvalidProxy
 	}
+	return u, nil
+}
 
-	req.Header.Set("User-Agent", userAgent)
+// SetProxy routes the outbound requests through the proxy,
+// the proxy of the environment is used again when raw is empty.
+func SetProxy(raw string) error {
+	fn := http.ProxyFromEnvironment
+	if len(raw) > 0 {
+		u, err := ParseProxy(raw)
+		if err != nil {
+			return err
+		}
+		fn = http.ProxyURL(u)
+	}
 
-	if header != nil {
-		for k, v := range header[0] {
-			req.Header.Set(k, v)
+	proxy.Lock()
+	proxy.fn = fn
+	proxy.Unlock()
+
+	// Connections opened through the previous proxy must not be reused
+	Transport.CloseIdleConnections()
+	return nil
+}
+
+// Requests failing with a network error, 429 or 5xx are retried up to MaxRetries times,
+// waiting Backoff doubled at every attempt with jitter, or as long as Retry-After asks.
+var (
+	MaxRetries = 3
+	Backoff    = 500 * time.Millisecond
+)
+
+// Requests asked to wait longer than this are not retried
+const maxRetryAfter = time.Minute
+
+// StatusError is returned when the upstream responds with a status which can not be used,
+// it wraps ErrRateLimited, ErrForbidden or ErrUpstreamUnavailable when the status matches.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	// How long the upstream asked to wait before retrying, zero when it did not
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	host := e.URL
+	if u, err := url.Parse(e.URL); err == nil {
+		host = u.Host
+	}
+
+	msg := fmt.Sprintf("%s responded with %d %s", host, e.StatusCode, http.StatusText(e.StatusCode))
+	if cause := e.Unwrap(); cause != nil {
+		msg = fmt.Sprintf("%s: %s", host, cause)
+	}
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry in %s", e.RetryAfter.Round(time.Second))
+	}
+	return msg
+}
+
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrUpstreamUnavailable
+	}
+	return nil
+}
+
+// RateLimit is the state of the rate limit told by the X-RateLimit-* headers of a response
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	// When the requests are allowed again once none remain
+	RetryAfter time.Time
+}
+
+var rateLimitObservers = struct {
+	sync.RWMutex
+	fns []func(u *url.URL, rl *RateLimit)
+}{}
+
+// OnRateLimit calls fn with the rate limit of every response which tells it
+func OnRateLimit(fn func(u *url.URL, rl *RateLimit)) {
+	rateLimitObservers.Lock()
+	defer rateLimitObservers.Unlock()
+	rateLimitObservers.fns = append(rateLimitObservers.fns, fn)
+}
+
+var upstreamRequests = metrics.NewCounter("nonbiri_upstream_requests_total",
+	"Requests sent upstream by scraper and status code, error when no response has been received.", "scraper", "code")
+
+var limiterWait = metrics.NewHistogram("nonbiri_limiter_wait_seconds",
+	"Time spent waiting on the rate limiters before sending requests.", nil, "limiter")
+
+// ObserveLimiterWait records the time a request has been held back by the rate limiter
+func ObserveLimiterWait(limiter string, d time.Duration) {
+	limiterWait.Observe(d.Seconds(), limiter)
+}
+
+// Endpoint is a base URL which may be replaced while requests are sent to it
+type Endpoint struct {
+	mu  sync.RWMutex
+	url string
+}
+
+func NewEndpoint(u string) *Endpoint {
+	return &Endpoint{url: u}
+}
+
+func (e *Endpoint) String() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.url
+}
+
+func (e *Endpoint) Set(u string) {
+	e.mu.Lock()
+	e.url = u
+	e.mu.Unlock()
+}
+
+// Names of the scrapers by the base URLs they send requests to
+var upstreams = struct {
+	sync.RWMutex
+	names map[string]string
+}{names: make(map[string]string)}
+
+// SetUpstream labels the metrics of the requests sent to the base URLs with the name of the scraper,
+// the base URLs previously set for it are replaced.
+func SetUpstream(name string, baseURLs ...string) {
+	upstreams.Lock()
+	defer upstreams.Unlock()
+
+	for baseURL, n := range upstreams.names {
+		if n == name {
+			delete(upstreams.names, baseURL)
 		}
 	}
+	for _, baseURL := range baseURLs {
+		upstreams.names[baseURL] = name
+	}
+}
 
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// CountUpstream counts a request of the scraper, statusCode is 0 when no response has been received
+func CountUpstream(scraper string, statusCode int) {
+	code := "error"
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
 	}
-	defer func() {
-		if err := res.Body.Close(); err != nil {
-			logger.Err.Println(err)
+	upstreamRequests.Inc(scraper, code)
+}
+
+// upstreamOf returns the name of the scraper whose base URL is the longest prefix of u
+func upstreamOf(u *url.URL) string {
+	upstreams.RLock()
+	defer upstreams.RUnlock()
+
+	name, matched := "other", 0
+	for baseURL, n := range upstreams.names {
+		if len(baseURL) > matched && strings.HasPrefix(u.String(), baseURL) {
+			name, matched = n, len(baseURL)
 		}
-	}()
-	return io.ReadAll(res.Body)
+	}
+	return name
+}
+
+func Get(ctx context.Context, url string, header ...map[string]string) ([]byte, error) {
+	return do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		setHeaders(req, header)
+		return req, nil
+	})
 }
 
-func PostJSON(url string, body any, header ...map[string]string) ([]byte, error) {
+func PostJSON(ctx context.Context, url string, body any, header ...map[string]string) ([]byte, error) {
 	buf, err := Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(buf))
-	if err != nil {
-		return nil, err
-	}
+	return do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		setHeaders(req, header)
+		return req, nil
+	})
+}
 
+func setHeaders(req *http.Request, header []map[string]string) {
 	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Content-Type", "application/json")
-
 	if header != nil {
 		for k, v := range header[0] {
 			req.Header.Set(k, v)
 		}
 	}
+}
 
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
+// do sends the request built by newRequest until it succeeds, may not be retried
+// or ctx is done, the bodies of error responses are only returned when they are JSON.
+func do(ctx context.Context, newRequest func() (*http.Request, error)) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := Client.Do(req)
+		if err != nil {
+			CountUpstream(upstreamOf(req.URL), 0)
+			if attempt < MaxRetries && isRetryableError(err) && Sleep(ctx, backoff(attempt)) == nil {
+				continue
+			}
+			return nil, err
+		}
+
+		CountUpstream(upstreamOf(req.URL), res.StatusCode)
+
+		buf, err := io.ReadAll(res.Body)
 		if err := res.Body.Close(); err != nil {
 			logger.Err.Println(err)
 		}
-	}()
-	return io.ReadAll(res.Body)
+		if err != nil {
+			if attempt < MaxRetries && ctx.Err() == nil && Sleep(ctx, backoff(attempt)) == nil {
+				continue
+			}
+			return nil, err
+		}
+
+		rl := parseRateLimit(res.Header)
+		if rl != nil {
+			notifyRateLimit(req.URL, rl)
+		}
+
+		if res.StatusCode < http.StatusBadRequest {
+			return buf, nil
+		}
+
+		statusErr := &StatusError{URL: req.URL.String(), StatusCode: res.StatusCode}
+		statusErr.RetryAfter = retryAfter(res.Header, rl)
+
+		if isRetryableStatus(res.StatusCode) {
+			wait := backoff(attempt)
+			if statusErr.RetryAfter > wait {
+				wait = statusErr.RetryAfter
+			}
+			if attempt < MaxRetries && wait <= maxRetryAfter {
+				if err := Sleep(ctx, wait); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, statusErr
+		}
+
+		// Client errors of APIs are described by their bodies
+		if isJSON(res.Header) {
+			return buf, nil
+		}
+		return nil, statusErr
+	}
+}
+
+// Sleep pauses for d, returns the error of ctx when it is done earlier
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isRetryableError tells whether the request failed because of the network,
+// instead of an invalid request.
+func isRetryableError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+func isJSON(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == "application/json" || mediaType == "application/problem+json"
+}
+
+// backoff doubles the wait at every attempt, randomized between half and one and a half of it
+func backoff(attempt int) time.Duration {
+	d := Backoff << attempt
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
+
+// parseRateLimit returns nil when the response does not tell its rate limit
+func parseRateLimit(header http.Header) *RateLimit {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return nil
+	}
+
+	rl := &RateLimit{Limit: limit}
+	rl.Remaining, _ = strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if v, err := strconv.ParseInt(header.Get("X-RateLimit-Retry-After"), 10, 64); err == nil {
+		rl.RetryAfter = time.Unix(v, 0)
+	}
+	return rl
+}
+
+func notifyRateLimit(u *url.URL, rl *RateLimit) {
+	rateLimitObservers.RLock()
+	defer rateLimitObservers.RUnlock()
+	for _, fn := range rateLimitObservers.fns {
+		fn(u, rl)
+	}
+}
+
+// retryAfter reads Retry-After as seconds or as a date,
+// X-RateLimit-Retry-After is used when it is missing.
+func retryAfter(header http.Header, rl *RateLimit) time.Duration {
+	if v := header.Get("Retry-After"); len(v) > 0 {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil && t.After(time.Now()) {
+			return time.Until(t)
+		}
+	}
+	if rl != nil && rl.Remaining == 0 && rl.RetryAfter.After(time.Now()) {
+		return time.Until(rl.RetryAfter)
+	}
+	return 0
 }
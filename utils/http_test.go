@@ -0,0 +1,137 @@
+package utils_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "nonbiri/constants"
+
+	"nonbiri/utils"
+)
+
+func TestGetRetries(t *testing.T) {
+	utils.Backoff = time.Millisecond
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"result":"ok"}`))
+		}
+	}))
+	defer server.Close()
+
+	buf, err := utils.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"result":"ok"}` || calls != 3 {
+		t.Errorf("got %q after %d calls", buf, calls)
+	}
+}
+
+func TestGetStatusError(t *testing.T) {
+	utils.Backoff = time.Millisecond
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>Bad Gateway</html>"))
+	}))
+	defer server.Close()
+
+	_, err := utils.Get(context.Background(), server.URL)
+	var statusErr *utils.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("got %v, want a status error", err)
+	}
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("%v does not wrap ErrUpstreamUnavailable", err)
+	}
+	if int(calls) != utils.MaxRetries+1 {
+		t.Errorf("got %d calls, want %d", calls, utils.MaxRetries+1)
+	}
+}
+
+func TestGetRateLimited(t *testing.T) {
+	utils.Backoff = time.Millisecond
+
+	// The wait asked for is too long to be retried
+	resetAt := time.Now().Add(10 * time.Minute).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "40")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Retry-After", strconv.FormatInt(resetAt, 10))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var observed *utils.RateLimit
+	utils.OnRateLimit(func(u *url.URL, rl *utils.RateLimit) {
+		if u.Host == server.Listener.Addr().String() {
+			observed = rl
+		}
+	})
+
+	_, err := utils.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got %v, want ErrRateLimited", err)
+	}
+	var statusErr *utils.StatusError
+	if !errors.As(err, &statusErr) || statusErr.RetryAfter < 9*time.Minute {
+		t.Errorf("got %v, want to retry in about 10m", err)
+	}
+	if observed == nil || observed.Limit != 40 || observed.RetryAfter.Unix() != resetAt {
+		t.Errorf("unexpected rate limit %+v", observed)
+	}
+}
+
+func TestGetClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"result":"error","errors":[{"status":404}]}`))
+	}))
+	defer server.Close()
+
+	// Errors described by the API are left to the caller
+	buf, err := utils.Get(context.Background(), server.URL)
+	if err != nil || len(buf) == 0 {
+		t.Errorf("got %q, %v, want the body of the error", buf, err)
+	}
+}
+
+func TestGetCancelled(t *testing.T) {
+	utils.Backoff = 20 * time.Second
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The backoff is interrupted instead of being waited for
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := utils.Get(ctx, server.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s to give up", elapsed)
+	}
+}
@@ -0,0 +1,184 @@
+// Package mail builds multipart emails and sends them over SMTP
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server is the SMTP server the emails are sent through
+type Server struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Connect over TLS (usually port 465) instead of upgrading with STARTTLS
+	TLS bool `json:"tls"`
+}
+
+// Inline is an image the HTML part refers to as cid:ID
+type Inline struct {
+	ID          string
+	ContentType string
+	Data        []byte
+}
+
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+	Inlines []*Inline
+}
+
+var Timeout = 30 * time.Second
+
+// Send delivers the message to every recipient
+func (s *Server) Send(msg *Message) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+
+	var conn net.Conn
+	var err error
+	if s.TLS {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: Timeout}, "tcp", addr, &tls.Config{ServerName: s.Host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, Timeout)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(Timeout))
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !s.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: s.Host}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(s.Username) > 0 {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(address(msg.From)); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := c.Rcpt(address(to)); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// address strips the display name of "Name <user@host>"
+func address(v string) string {
+	if i := strings.LastIndex(v, "<"); i >= 0 {
+		return strings.TrimSuffix(v[i+1:], ">")
+	}
+	return v
+}
+
+func boundary() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Bytes encodes the message as multipart/alternative,
+// the HTML part is wrapped in multipart/related when it has inline images.
+func (m *Message) Bytes() []byte {
+	buf := &bytes.Buffer{}
+	header := func(k, v string) {
+		fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+	}
+
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	alternative := boundary()
+	header("Content-Type", fmt.Sprintf(`multipart/alternative; boundary="%s"`, alternative))
+	buf.WriteString("\r\n")
+
+	fmt.Fprintf(buf, "--%s\r\n", alternative)
+	writeText(buf, "text/plain", m.Text)
+
+	fmt.Fprintf(buf, "--%s\r\n", alternative)
+	if len(m.Inlines) == 0 {
+		writeText(buf, "text/html", m.HTML)
+	} else {
+		related := boundary()
+		fmt.Fprintf(buf, "Content-Type: multipart/related; boundary=\"%s\"\r\n\r\n", related)
+
+		fmt.Fprintf(buf, "--%s\r\n", related)
+		writeText(buf, "text/html", m.HTML)
+
+		for _, inline := range m.Inlines {
+			fmt.Fprintf(buf, "--%s\r\n", related)
+			fmt.Fprintf(buf, "Content-Type: %s\r\n", inline.ContentType)
+			buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+			fmt.Fprintf(buf, "Content-ID: <%s>\r\n", inline.ID)
+			buf.WriteString("Content-Disposition: inline\r\n\r\n")
+			writeBase64(buf, inline.Data)
+		}
+		fmt.Fprintf(buf, "--%s--\r\n", related)
+	}
+	fmt.Fprintf(buf, "--%s--\r\n", alternative)
+	return buf.Bytes()
+}
+
+func writeText(buf *bytes.Buffer, contentType, text string) {
+	fmt.Fprintf(buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(buf)
+	w.Write([]byte(text))
+	w.Close()
+	buf.WriteString("\r\n")
+}
+
+// writeBase64 wraps the encoded data at 76 characters
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
@@ -0,0 +1,190 @@
+package mail
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// received is a message accepted by the sink
+type received struct {
+	From string
+	To   []string
+	Data string
+}
+
+// sink is a minimal SMTP server accepting every message
+func sink(t *testing.T) (*Server, <-chan *received) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	messages := make(chan *received, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+		reply("220 localhost ESMTP sink")
+
+		msg := &received{}
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(line)
+
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(cmd, "AUTH PLAIN"):
+				reply("235 Authenticated")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				msg.From = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				msg.To = append(msg.To, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				data := &strings.Builder{}
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(line, "."))
+				}
+				msg.Data = data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				messages <- msg
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	return &Server{Host: "127.0.0.1", Port: addr.Port, Username: "user", Password: "pass"}, messages
+}
+
+func TestSend(t *testing.T) {
+	server, messages := sink(t)
+
+	msg := &Message{
+		From:    "Nonbiri <nonbiri@localhost>",
+		To:      []string{"reader@localhost", "other@localhost"},
+		Subject: "3 new chapters – Nonbiri",
+		Text:    "Chapter 1\nChapter 2",
+		HTML:    `<p>Chapter 1</p><img src="cid:cover">`,
+		Inlines: []*Inline{{ID: "cover", ContentType: "image/jpeg", Data: []byte("jpeg data")}},
+	}
+	if err := server.Send(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-messages
+	if got.From != "nonbiri@localhost" {
+		t.Errorf("from = %q", got.From)
+	}
+	if strings.Join(got.To, ",") != "reader@localhost,other@localhost" {
+		t.Errorf("to = %v", got.To)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(got.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q, %v", mediaType, err)
+	}
+
+	parts := multipart.NewReader(m.Body, params["boundary"])
+
+	text, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(text)
+	if !strings.HasPrefix(text.Header.Get("Content-Type"), "text/plain") || strings.ReplaceAll(string(body), "\r\n", "\n") != msg.Text {
+		t.Errorf("text part = %q", body)
+	}
+
+	related, err := parts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, _ = mime.ParseMediaType(related.Header.Get("Content-Type"))
+	if mediaType != "multipart/related" {
+		t.Fatalf("html part = %q", mediaType)
+	}
+
+	relatedParts := multipart.NewReader(related, params["boundary"])
+	html, err := relatedParts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(html)
+	if string(body) != msg.HTML {
+		t.Errorf("html part = %q", body)
+	}
+
+	image, err := relatedParts.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.Header.Get("Content-ID") != "<cover>" {
+		t.Errorf("content id = %q", image.Header.Get("Content-ID"))
+	}
+}
+
+func TestSendRejected(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "554 No SMTP service here\r\n")
+	}()
+
+	server := &Server{Host: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port}
+	if err := server.Send(&Message{From: "a@localhost", To: []string{"b@localhost"}}); err == nil {
+		t.Error("expected an error")
+	}
+}
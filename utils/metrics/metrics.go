@@ -0,0 +1,304 @@
+// Package metrics collects counters, gauges and histograms
+// and writes them in the text exposition format of Prometheus.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are upper bounds in seconds, suited to requests and queries
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+var registry = struct {
+	metrics map[string]metric
+	names   []string
+	sync.Mutex
+}{metrics: make(map[string]metric)}
+
+func register(name string, m metric) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, exists := registry.metrics[name]; exists {
+		panic("metrics: " + name + " is already registered")
+	}
+	registry.metrics[name] = m
+	registry.names = append(registry.names, name)
+	sort.Strings(registry.names)
+}
+
+// vec holds the series of a metric, one per combination of label values
+type vec[T any] struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+	series map[string]*T
+	values map[string][]string
+	init   func() *T
+	sync.Mutex
+}
+
+func newVec[T any](name, help, kind string, labels []string, init func() *T) *vec[T] {
+	return &vec[T]{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+		init:   init,
+	}
+}
+
+// with returns the series of the label values, must be called with v locked
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = v.init()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each calls fn with the series sorted by their label values, must be called with v locked
+func (v *vec[T]) each(fn func(labels string, s *T)) {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fn(formatLabels(v.labels, v.values[key]), v.series[key])
+	}
+}
+
+func (v *vec[T]) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, escape(v.help, false))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.kind)
+}
+
+// Counter is a value which only increases
+type Counter struct {
+	vec *vec[float64]
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{newVec(name, help, "counter", labels, func() *float64 { return new(float64) })}
+	register(name, c)
+	return c
+}
+
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increases the counter of the label values by n, which must not be negative
+func (c *Counter) Add(n float64, values ...string) {
+	c.vec.Lock()
+	defer c.vec.Unlock()
+	*c.vec.with(values) += n
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.vec.Lock()
+	defer c.vec.Unlock()
+
+	c.vec.header(w)
+	c.vec.each(func(labels string, v *float64) {
+		fmt.Fprintf(w, "%s%s %s\n", c.vec.name, labels, formatFloat(*v))
+	})
+}
+
+// Gauge is a value which goes up and down
+type Gauge struct {
+	vec *vec[float64]
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newVec(name, help, "gauge", labels, func() *float64 { return new(float64) })}
+	register(name, g)
+	return g
+}
+
+func (g *Gauge) Set(n float64, values ...string) {
+	g.vec.Lock()
+	defer g.vec.Unlock()
+	*g.vec.with(values) = n
+}
+
+func (g *Gauge) Add(n float64, values ...string) {
+	g.vec.Lock()
+	defer g.vec.Unlock()
+	*g.vec.with(values) += n
+}
+
+func (g *Gauge) Inc(values ...string) {
+	g.Add(1, values...)
+}
+
+func (g *Gauge) Dec(values ...string) {
+	g.Add(-1, values...)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.vec.Lock()
+	defer g.vec.Unlock()
+
+	g.vec.header(w)
+	g.vec.each(func(labels string, v *float64) {
+		fmt.Fprintf(w, "%s%s %s\n", g.vec.name, labels, formatFloat(*v))
+	})
+}
+
+// Histogram counts observations in buckets of upper bounds
+type Histogram struct {
+	vec     *vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64 // Per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates a histogram with buckets of the upper bounds, DefaultBuckets when nil
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &Histogram{buckets: buckets}
+	h.vec = newVec(name, help, "histogram", labels, func() *histogram {
+		return &histogram{counts: make([]uint64, len(buckets))}
+	})
+	register(name, h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.vec.Lock()
+	defer h.vec.Unlock()
+
+	s := h.vec.with(values)
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// Since observes the seconds elapsed since start
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.vec.Lock()
+	defer h.vec.Unlock()
+
+	h.vec.header(w)
+	h.vec.each(func(labels string, s *histogram) {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.vec.name, withLe(labels, formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.vec.name, withLe(labels, "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.vec.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.vec.name, labels, s.count)
+	})
+}
+
+// Write writes every registered metric sorted by name
+func Write(w io.Writer) error {
+	registry.Lock()
+	metrics := make([]metric, len(registry.names))
+	for i, name := range registry.names {
+		metrics[i] = registry.metrics[name]
+	}
+	registry.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	return buf.Flush()
+}
+
+// Handler serves the registered metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = Write(w)
+	})
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escape(values[i], true))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLe adds the upper bound of a bucket to the labels
+func withLe(labels, bound string) string {
+	le := fmt.Sprintf(`le="%s"`, bound)
+	if len(labels) == 0 {
+		return "{" + le + "}"
+	}
+	return labels[:len(labels)-1] + "," + le + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escape escapes backslashes and line feeds, and double quotes of label values
+func escape(s string, quote bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quote {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nonbiri/utils/metrics"
+)
+
+func write(t *testing.T) string {
+	var buf bytes.Buffer
+	if err := metrics.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCounter(t *testing.T) {
+	c := metrics.NewCounter("test_requests_total", "Requests by code.", "scraper", "code")
+	c.Inc("mangadex", "200")
+	c.Add(2, "mangadex", "200")
+	c.Inc("other", `say "hi"`+"\n")
+
+	out := write(t)
+	for _, want := range []string{
+		"# HELP test_requests_total Requests by code.\n",
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{scraper="mangadex",code="200"} 3` + "\n",
+		`test_requests_total{scraper="other",code="say \"hi\"\n"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("%q is missing from\n%s", want, out)
+		}
+	}
+}
+
+func TestGauge(t *testing.T) {
+	g := metrics.NewGauge("test_connections", "Open connections.")
+	g.Inc()
+	g.Inc()
+	g.Dec()
+
+	if out := write(t); !strings.Contains(out, "test_connections 1\n") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := metrics.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "task")
+	h.Observe(0.05, "GetManga")
+	h.Observe(0.1, "GetManga")
+	h.Observe(0.5, "GetManga")
+	h.Observe(3, "GetManga")
+
+	want := `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{task="GetManga",le="0.1"} 2
+test_duration_seconds_bucket{task="GetManga",le="1"} 3
+test_duration_seconds_bucket{task="GetManga",le="+Inf"} 4
+test_duration_seconds_sum{task="GetManga"} 3.65
+test_duration_seconds_count{task="GetManga"} 4
+`
+	if out := write(t); !strings.Contains(out, want) {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	metrics.NewCounter("test_handled_total", "Handled.").Inc()
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != metrics.ContentType {
+		t.Errorf("got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "test_handled_total 1\n") {
+		t.Errorf("unexpected body\n%s", rec.Body)
+	}
+}
@@ -0,0 +1,267 @@
+// Package opds encodes catalogs as OPDS 1.2 (Atom) or OPDS 2.0 (JSON)
+package opds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"time"
+)
+
+const (
+	NavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	AcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	SearchType      = "application/opensearchdescription+xml"
+	ManifestType    = "application/opds+json"
+	CBZType         = "application/vnd.comicbook+zip"
+)
+
+// Feed is a catalog page, entries navigate to other pages
+// and publications are the files which can be acquired.
+type Feed struct {
+	ID      string
+	Title   string
+	Self    string
+	Start   string
+	Updated time.Time
+	// OpenSearch description of the catalog
+	Search string
+
+	Entries      []*Entry
+	Publications []*Publication
+}
+
+// Entry navigates to another catalog page
+type Entry struct {
+	ID        string
+	Title     string
+	Href      string
+	Summary   string
+	Updated   time.Time
+	Image     string
+	Thumbnail string
+	// Leads to publications instead of more entries
+	Acquisition bool
+}
+
+// Publication is a file which can be acquired
+type Publication struct {
+	ID        string
+	Title     string
+	Author    string
+	Summary   string
+	Updated   time.Time
+	Href      string
+	Type      string
+	Image     string
+	Thumbnail string
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	OPDS    string      `xml:"xmlns:opds,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Content *atomText   `xml:"content,omitempty"`
+	Links   []atomLink  `xml:"link"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+func imageLinks(image, thumbnail string) (links []atomLink) {
+	if len(image) > 0 {
+		links = append(links, atomLink{Rel: "http://opds-spec.org/image", Href: image, Type: "image/jpeg"})
+	}
+	if len(thumbnail) > 0 {
+		links = append(links, atomLink{Rel: "http://opds-spec.org/image/thumbnail", Href: thumbnail, Type: "image/jpeg"})
+	}
+	return
+}
+
+func text(v string) *atomText {
+	if len(v) == 0 {
+		return nil
+	}
+	return &atomText{Type: "text", Value: v}
+}
+
+// XML encodes the feed as an OPDS 1.2 catalog
+func (f *Feed) XML() ([]byte, error) {
+	kind := NavigationType
+	if len(f.Publications) > 0 {
+		kind = AcquisitionType
+	}
+
+	doc := atomFeed{
+		OPDS:    "http://opds-spec.org/2010/catalog",
+		ID:      f.ID,
+		Title:   f.Title,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Href: f.Self, Type: kind},
+			{Rel: "start", Href: f.Start, Type: NavigationType},
+		},
+	}
+	if len(f.Search) > 0 {
+		doc.Links = append(doc.Links, atomLink{Rel: "search", Href: f.Search, Type: SearchType})
+	}
+
+	for _, e := range f.Entries {
+		kind := NavigationType
+		if e.Acquisition {
+			kind = AcquisitionType
+		}
+
+		links := append([]atomLink{{Rel: "subsection", Href: e.Href, Type: kind}}, imageLinks(e.Image, e.Thumbnail)...)
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: e.Updated.UTC().Format(time.RFC3339),
+			Content: text(e.Summary),
+			Links:   links,
+		})
+	}
+
+	for _, p := range f.Publications {
+		links := append([]atomLink{{Rel: "http://opds-spec.org/acquisition", Href: p.Href, Type: p.Type}}, imageLinks(p.Image, p.Thumbnail)...)
+		entry := atomEntry{
+			ID:      p.ID,
+			Title:   p.Title,
+			Updated: p.Updated.UTC().Format(time.RFC3339),
+			Content: text(p.Summary),
+			Links:   links,
+		}
+		if len(p.Author) > 0 {
+			entry.Author = &atomAuthor{Name: p.Author}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	buf, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), buf...), nil
+}
+
+type manifest struct {
+	Metadata     manifestMetadata `json:"metadata"`
+	Links        []manifestLink   `json:"links"`
+	Navigation   []manifestLink   `json:"navigation,omitempty"`
+	Publications []publication    `json:"publications,omitempty"`
+}
+
+type manifestMetadata struct {
+	Identifier string `json:"identifier,omitempty"`
+	Title      string `json:"title"`
+	Author     string `json:"author,omitempty"`
+	Modified   string `json:"modified,omitempty"`
+	Summary    string `json:"description,omitempty"`
+}
+
+type manifestLink struct {
+	Rel       string `json:"rel,omitempty"`
+	Href      string `json:"href"`
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+type publication struct {
+	Metadata manifestMetadata `json:"metadata"`
+	Links    []manifestLink   `json:"links"`
+	Images   []manifestLink   `json:"images,omitempty"`
+}
+
+func images(image, thumbnail string) (links []manifestLink) {
+	if len(thumbnail) > 0 {
+		links = append(links, manifestLink{Href: thumbnail, Type: "image/jpeg"})
+	}
+	if len(image) > 0 {
+		links = append(links, manifestLink{Href: image, Type: "image/jpeg"})
+	}
+	return
+}
+
+// JSON encodes the feed as an OPDS 2.0 catalog,
+// searchTemplate is the URI template of the search, e.g. /search{?query}.
+func (f *Feed) JSON(searchTemplate string) ([]byte, error) {
+	doc := manifest{
+		Metadata: manifestMetadata{Title: f.Title, Modified: f.Updated.UTC().Format(time.RFC3339)},
+		Links: []manifestLink{
+			{Rel: "self", Href: f.Self, Type: ManifestType},
+			{Rel: "start", Href: f.Start, Type: ManifestType},
+		},
+	}
+	if len(searchTemplate) > 0 {
+		doc.Links = append(doc.Links, manifestLink{Rel: "search", Href: searchTemplate, Type: ManifestType, Templated: true})
+	}
+
+	for _, e := range f.Entries {
+		doc.Navigation = append(doc.Navigation, manifestLink{Href: e.Href, Type: ManifestType, Title: e.Title})
+	}
+
+	for _, p := range f.Publications {
+		doc.Publications = append(doc.Publications, publication{
+			Metadata: manifestMetadata{
+				Identifier: p.ID,
+				Title:      p.Title,
+				Author:     p.Author,
+				Modified:   p.Updated.UTC().Format(time.RFC3339),
+				Summary:    p.Summary,
+			},
+			Links:  []manifestLink{{Rel: "http://opds-spec.org/acquisition", Href: p.Href, Type: p.Type}},
+			Images: images(p.Image, p.Thumbnail),
+		})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type openSearch struct {
+	XMLName     xml.Name      `xml:"http://a9.com/-/spec/opensearch/1.1/ OpenSearchDescription"`
+	ShortName   string        `xml:"ShortName"`
+	Description string        `xml:"Description"`
+	URL         openSearchURL `xml:"Url"`
+}
+
+type openSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// OpenSearch returns the description of a search,
+// template contains {searchTerms} where the terms are substituted.
+func OpenSearch(title, template string) ([]byte, error) {
+	buf, err := xml.MarshalIndent(openSearch{
+		ShortName:   title,
+		Description: title,
+		URL:         openSearchURL{Type: AcquisitionType, Template: template},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), buf...), nil
+}
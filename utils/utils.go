@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 )
 
 var nullBytes = []byte("null")
@@ -41,3 +42,40 @@ func SliceToBytes(v any) ([]byte, error) {
 
 	return buf, nil
 }
+
+func Contains[T comparable](slice []T, v T) bool {
+	for _, x := range slice {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinErrors returns an error wrapping the non-nil errors, nil when there are none
+func JoinErrors(errs ...error) error {
+	var result joinedErrors
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+type joinedErrors []error
+
+func (e joinedErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e joinedErrors) Unwrap() []error {
+	return e
+}
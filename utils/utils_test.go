@@ -0,0 +1,23 @@
+package utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"nonbiri/utils"
+)
+
+func TestJoinErrors(t *testing.T) {
+	if err := utils.JoinErrors(nil, nil); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	first, second := errors.New("first"), errors.New("second")
+	err := utils.JoinErrors(first, nil, second)
+	if err == nil || err.Error() != "first\nsecond" {
+		t.Fatalf("got %v", err)
+	}
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("%v does not wrap both errors", err)
+	}
+}
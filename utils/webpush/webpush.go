@@ -0,0 +1,237 @@
+// Package webpush sends encrypted push messages (RFC 8291)
+// authenticated with VAPID (RFC 8292) to browser push services.
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("invalid push key")
+
+// ErrGone means the subscription has expired or has been revoked
+var ErrGone = errors.New("push subscription is gone")
+
+var Client = &http.Client{Timeout: 15 * time.Second}
+
+var encoding = base64.RawURLEncoding
+
+// Keys is a VAPID key pair encoded as unpadded base64url,
+// the public key is the applicationServerKey of the browser subscription.
+type Keys struct {
+	Public  string `json:"public"`
+	Private string `json:"private"`
+}
+
+// Subscription is the PushSubscription of a browser
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	P256dh   string `json:"p256dh"`
+	Auth     string `json:"auth"`
+}
+
+// Options of a push message
+type Options struct {
+	// Contact of the application server, mailto: or https: URL
+	Subject string
+	// Seconds the push service keeps the message while the browser is offline
+	TTL int
+	// Messages with the same topic replace each other while undelivered
+	Topic string
+}
+
+func GenerateKeys() (*Keys, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{
+		Public:  encoding.EncodeToString(elliptic.Marshal(elliptic.P256(), key.X, key.Y)),
+		Private: encoding.EncodeToString(key.D.FillBytes(make([]byte, 32))),
+	}, nil
+}
+
+func (k *Keys) privateKey() (*ecdsa.PrivateKey, error) {
+	d, err := encoding.DecodeString(k.Private)
+	if err != nil || len(d) != 32 {
+		return nil, ErrInvalidKey
+	}
+	pub, err := encoding.DecodeString(k.Public)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+	if x == nil {
+		return nil, ErrInvalidKey
+	}
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y},
+		D:         new(big.Int).SetBytes(d),
+	}, nil
+}
+
+// Send encrypts the payload for the subscription and posts it to its push service
+func Send(ctx context.Context, sub *Subscription, payload []byte, keys *Keys, opts Options) error {
+	body, err := Encrypt(sub, payload)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("invalid push endpoint: %s", sub.Endpoint)
+	}
+
+	token, err := vapidToken(u.Scheme+"://"+u.Host, keys, opts.Subject)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(opts.TTL))
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, keys.Public))
+	if len(opts.Topic) > 0 {
+		req.Header.Set("Topic", opts.Topic)
+	}
+
+	res, err := Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrGone
+	case res.StatusCode >= 300:
+		return fmt.Errorf("push service responded %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// vapidToken signs a JWT (ES256) for the origin of the push service
+func vapidToken(audience string, keys *Keys, subject string) (string, error) {
+	key, err := keys.privateKey()
+	if err != nil {
+		return "", err
+	}
+
+	header := encoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": audience,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return unsigned + "." + encoding.EncodeToString(sig), nil
+}
+
+// Encrypt encodes the payload as a single aes128gcm record (RFC 8188)
+// with a key derived from an ephemeral ECDH exchange (RFC 8291).
+func Encrypt(sub *Subscription, payload []byte) ([]byte, error) {
+	uaPublic, err := encoding.DecodeString(sub.P256dh)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	authSecret, err := encoding.DecodeString(sub.Auth)
+	if err != nil || len(authSecret) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	curve := elliptic.P256()
+	uaX, uaY := elliptic.Unmarshal(curve, uaPublic)
+	if uaX == nil {
+		return nil, ErrInvalidKey
+	}
+
+	asPrivate, asX, asY, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := elliptic.Marshal(curve, asX, asY)
+
+	sx, _ := curve.ScalarMult(uaX, uaY, asPrivate)
+	shared := sx.FillBytes(make([]byte, 32))
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	info := append([]byte("WebPush: info\x00"), uaPublic...)
+	info = append(info, asPublic...)
+	ikm := hkdf(authSecret, shared, info, 32)
+
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last record is delimited by 0x02
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	recordSize := uint32(len(plaintext) + gcm.Overhead())
+
+	header := make([]byte, 16+4+1)
+	copy(header, salt)
+	binary.BigEndian.PutUint32(header[16:], recordSize)
+	header[20] = byte(len(asPublic))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdf derives length bytes (at most 32) from the input keying material (RFC 5869)
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
@@ -0,0 +1,155 @@
+package webpush
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// browser holds the keys a browser generates for its subscription
+type browser struct {
+	private []byte
+	public  []byte
+	auth    []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	private, x, y, err := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &browser{private: private, public: elliptic.Marshal(elliptic.P256(), x, y), auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) *Subscription {
+	return &Subscription{
+		Endpoint: endpoint,
+		P256dh:   encoding.EncodeToString(b.public),
+		Auth:     encoding.EncodeToString(b.auth),
+	}
+}
+
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	salt := body[:16]
+	recordSize := binary.BigEndian.Uint32(body[16:20])
+	idLen := int(body[20])
+	asPublic := body[21 : 21+idLen]
+	record := body[21+idLen:]
+	if int(recordSize) != len(record) {
+		t.Fatalf("got record of %d bytes, want %d", len(record), recordSize)
+	}
+
+	curve := elliptic.P256()
+	x, y := elliptic.Unmarshal(curve, asPublic)
+	sx, _ := curve.ScalarMult(x, y, b.private)
+
+	info := append([]byte("WebPush: info\x00"), b.public...)
+	info = append(info, asPublic...)
+	ikm := hkdf(b.auth, sx.FillBytes(make([]byte, 32)), info, 32)
+
+	block, _ := aes.NewCipher(hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16))
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12), record, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatal("missing padding delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func verifyToken(t *testing.T, header string, keys *Keys, audience string) {
+	var token, k string
+	for _, part := range strings.Split(strings.TrimPrefix(header, "vapid "), ", ") {
+		if strings.HasPrefix(part, "t=") {
+			token = part[2:]
+		} else if strings.HasPrefix(part, "k=") {
+			k = part[2:]
+		}
+	}
+	if k != keys.Public {
+		t.Errorf("got key %s, want %s", k, keys.Public)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed token %s", token)
+	}
+
+	pub, _ := encoding.DecodeString(keys.Public)
+	x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+	sig, _ := encoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, digest[:], r, s) {
+		t.Error("invalid token signature")
+	}
+
+	buf, _ := encoding.DecodeString(parts[1])
+	var claims map[string]any
+	json.Unmarshal(buf, &claims)
+	if claims["aud"] != audience || claims["sub"] != "mailto:admin@localhost" {
+		t.Errorf("unexpected claims %v", claims)
+	}
+}
+
+func TestSend(t *testing.T) {
+	keys, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := newBrowser(t)
+
+	var received []byte
+	var authorization, contentEncoding, ttl string
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		authorization = r.Header.Get("Authorization")
+		contentEncoding = r.Header.Get("Content-Encoding")
+		ttl = r.Header.Get("TTL")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer service.Close()
+
+	payload := []byte(`{"title":"New chapter"}`)
+	opts := Options{Subject: "mailto:admin@localhost", TTL: 60}
+	if err := Send(context.Background(), b.subscription(service.URL+"/push/1"), payload, keys, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if contentEncoding != "aes128gcm" || ttl != "60" {
+		t.Errorf("got encoding %s and TTL %s", contentEncoding, ttl)
+	}
+	verifyToken(t, authorization, keys, service.URL)
+	if got := b.decrypt(t, received); string(got) != string(payload) {
+		t.Errorf("got payload %s, want %s", got, payload)
+	}
+}
+
+func TestSendGone(t *testing.T) {
+	keys, _ := GenerateKeys()
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer service.Close()
+
+	err := Send(context.Background(), newBrowser(t).subscription(service.URL), []byte("{}"), keys, Options{})
+	if err != ErrGone {
+		t.Errorf("got %v, want ErrGone", err)
+	}
+}
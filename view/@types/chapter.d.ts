@@ -6,6 +6,7 @@ declare global {
     mangaId: string;
 
     pages?: string[];
+    dataSaverPages?: string[];
     history?: ReadState;
 
     mangaTitle?: string;
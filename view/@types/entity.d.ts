@@ -6,4 +6,17 @@ declare interface Entity {
 declare type Author = Entity;
 declare type Artists = Entity;
 declare type Group = Entity;
-declare type Tag = Entity;
+
+declare interface Tag extends Entity {
+  group?: string;
+  description?: string;
+}
+
+declare interface TagGroup {
+  name: string;
+  tags: Tag[];
+}
+
+declare interface EntityData extends Entity {
+  entries?: Manga[];
+}
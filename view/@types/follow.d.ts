@@ -0,0 +1,31 @@
+import { Language } from "../src/constants";
+
+declare global {
+  // Scanlation groups and authors, as typed by MangaDex
+  type FollowType = "scanlation_group" | "author";
+
+  interface EntityFollow {
+    id: string;
+    type: FollowType;
+    name: string;
+    followedAt?: number;
+    lastChecked?: number;
+  }
+
+  // A chapter of a followed group, or a manga of a followed author
+  interface FollowedRelease {
+    id: string;
+    followId: string;
+    mangaId: string;
+    mangaTitle: string;
+    cover: string;
+    title?: string;
+    volume?: string;
+    chapter?: string;
+    language?: Language;
+    publishAt?: number;
+    createdAt: number;
+    followName?: string;
+    followType?: FollowType;
+  }
+}
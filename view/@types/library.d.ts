@@ -2,4 +2,65 @@ declare interface LibraryUpdateState {
   progress?: number;
   total?: number;
   current?: string;
+  newChapters?: number;
+  failed?: number;
+  paused?: boolean;
+  kind?: import("../src/constants").UpdateKind;
+  startedAt?: number;
+  eta?: number;
+  entries?: MangaUpdate[];
+}
+
+declare interface MangaUpdate {
+  id: string;
+  title: string;
+  status: import("../src/constants").UpdateStatus;
+  newChapters: number;
+  attempts?: number;
+  error?: string;
+  failure?: import("../src/constants").Failure;
+}
+
+declare interface UpdateHistory {
+  runs: UpdateRun[];
+  failing: FailingUpdate[];
+}
+
+declare interface UpdateRun {
+  id: number;
+  startedAt: number;
+  endedAt: number;
+  scheduled: boolean;
+  kind: import("../src/constants").UpdateKind;
+  cancelled: boolean;
+  total: number;
+  newChapters: number;
+  failed: number;
+  entries?: UpdateRunEntry[];
+}
+
+declare interface UpdateRunEntry {
+  runId: number;
+  mangaId: string;
+  title: string;
+  status: import("../src/constants").UpdateStatus;
+  newChapters: number;
+  attempts: number;
+  error?: string;
+  failure: import("../src/constants").Failure;
+}
+
+declare interface FailingUpdate {
+  mangaId: string;
+  title: string;
+  error: string;
+  failure: import("../src/constants").Failure;
+  failures: number;
+  lastFailedAt: number;
+}
+
+declare interface NetworkState {
+  online: boolean;
+  offline: boolean;
+  queued: number;
 }
@@ -1,4 +1,4 @@
-import { Demographic, FollowState, Language, Rating, Status } from "../src/constants";
+import { Demographic, FollowState, Language, Rating, Status, UpdateMode } from "../src/constants";
 
 declare global {
   interface Manga extends MangaMetadata {
@@ -14,6 +14,16 @@ declare global {
     followed?: boolean;
     followState?: FollowState;
     followedAt?: number;
+
+    lastChecked?: number;
+    nextCheck?: number;
+    updateMode?: UpdateMode;
+    // Consecutive failed checks, unavailable once MangaDex no longer has the manga
+    checkFailures?: number;
+    unavailable?: boolean;
+    // Replaces the preferred languages of the chapters
+    languages?: Language[];
+    preferredGroups?: Entity[];
   }
 
   interface MangaMetadata {
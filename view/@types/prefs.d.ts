@@ -1,14 +1,31 @@
-import { Language, Order, PageDirection, PageScale, Rating, SidebarPosition, Sort } from "../src/constants";
+import {
+  FollowState,
+  Language,
+  Order,
+  PageDirection,
+  PageQuality,
+  PageScale,
+  Rating,
+  SidebarPosition,
+  Sort,
+  UpdateKind
+} from "../src/constants";
 
 declare global {
   interface Prefs {
     browse: BrowsePreference;
     library: LibraryPreference;
     reader: ReaderPreference;
+    network: NetworkPreference;
+    feed: FeedPreference;
+    email: EmailPreference;
+    groups: GroupPreference;
   }
 
   interface BrowsePreference {
     language: Language;
+    // Translations of the chapters, ordered by priority
+    languages: Language[];
     origins: Language[];
     excludedTags: string[];
     ratings: Rating[];
@@ -17,6 +34,20 @@ declare global {
   interface LibraryPreference {
     sort: Sort;
     order: Order;
+    schedules?: UpdateSchedule[];
+    quietHours?: QuietHours;
+  }
+
+  interface UpdateSchedule {
+    kind: UpdateKind;
+    cron?: string;
+    times?: string[]; // HH:MM
+    followStates?: FollowState[];
+  }
+
+  interface QuietHours {
+    start?: string; // HH:MM
+    end?: string;
   }
 
   interface ReaderPreference {
@@ -33,10 +64,60 @@ declare global {
 
     maxPreloads: number;
     maxParallel: number;
+    // Can be overridden per device, see utils/quality.ts
+    quality: PageQuality;
 
     keybinds: Keybinds;
   }
 
+  interface NetworkPreference {
+    offline: boolean;
+    endpoints: Endpoints;
+    // http://, https:// or socks5:// URL every outbound request goes through
+    proxy?: string;
+  }
+
+  // The official endpoints are used when they are empty
+  interface Endpoints {
+    mangadex?: string;
+    images?: string;
+    anilist?: string;
+    // Where MangaDex@Home is told whether the pages were retrieved
+    reports?: string;
+  }
+
+  // The feed is served as /feed/atom?token= or /feed/rss?token=,
+  // the OPDS catalog as /opds/v1?token= or /opds/v2?token=
+  interface FeedPreference {
+    enabled: boolean;
+    token: string;
+  }
+
+  interface SMTPServer {
+    host: string;
+    port: number;
+    username?: string;
+    password?: string;
+    // Connect over TLS instead of upgrading with STARTTLS
+    tls: boolean;
+  }
+
+  // The digest of new chapters is sent at every time matching the cron schedule
+  interface EmailPreference {
+    enabled: boolean;
+    smtp: SMTPServer;
+    from: string;
+    to: string[];
+    schedule: string;
+    baseURL: string;
+  }
+
+  // Chapters of the blocked groups never appear in the updates or the notifications
+  interface GroupPreference {
+    preferred: Entity[];
+    blocked: Entity[];
+  }
+
   interface Keybinds {
     previousChapter: string;
     nextChapter: string;
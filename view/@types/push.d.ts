@@ -0,0 +1,16 @@
+import { FollowState } from "../src/constants";
+
+declare global {
+  interface PushFilters {
+    mangaIds?: string[];
+    followStates?: FollowState[];
+  }
+
+  interface PushSubscriptionData extends PushFilters {
+    id?: number;
+    endpoint: string;
+    p256dh: string;
+    auth: string;
+    createdAt?: number;
+  }
+}
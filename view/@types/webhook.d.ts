@@ -0,0 +1,29 @@
+import { DeliveryStatus, WebhookEvent } from "../src/constants";
+
+declare global {
+  interface Webhook {
+    id?: number;
+    url: string;
+    events: WebhookEvent[]; // Empty subscribes to every event
+    template?: string;
+    contentType?: string;
+    // Sent when saving only, the stored secret is kept when it is omitted
+    secret?: string;
+    hasSecret?: boolean;
+    enabled: boolean;
+    createdAt?: number;
+  }
+
+  interface WebhookDelivery {
+    id: number;
+    webhookId: number;
+    event: WebhookEvent;
+    body: string;
+    status: DeliveryStatus;
+    statusCode: number;
+    attempts: number;
+    error?: string;
+    createdAt: number;
+    deliveredAt?: number;
+  }
+}
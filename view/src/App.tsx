@@ -3,6 +3,7 @@ import { render } from "react-dom";
 import { BrowserRouter as Router, Route, Switch, useLocation } from "react-router-dom";
 import AppContext from "./AppContext";
 import Browse from "./Components/Browse";
+import EntityPage from "./Components/EntityPage";
 import History from "./Components/History";
 import Library from "./Components/Library";
 import Manga from "./Components/Manga";
@@ -158,6 +159,8 @@ const App = (initialContext: AppContext) => {
         <Route path={routes.browse} component={Browse} />
         <Route path={`${routes.manga}/:id`} component={Manga} key={pathname} />
         <Route path={`${routes.reader}/:id/:cid`} component={Reader} key={pathname} />
+        <Route path={`${routes.author}/:id`} key={pathname} render={() => <EntityPage type="author" />} />
+        <Route path={`${routes.tag}/:id`} key={pathname} render={() => <EntityPage type="tag" />} />
         <Route path={routes.updates} component={Updates} />
         <Route path={routes.history} component={History} />
         <Route path={routes.library} component={Library} />
@@ -174,7 +177,7 @@ const main = async () => {
   await websocket.Init();
 
   let prefs: Prefs;
-  let tags: Tag[];
+  let tagGroups: TagGroup[];
   let library: Manga[];
   let error: string;
 
@@ -187,12 +190,13 @@ const main = async () => {
   }
 
   // eslint-disable-next-line prefer-const
-  ({ response: tags, error } = await GetTags());
+  ({ response: tagGroups, error } = await GetTags());
   if (error) {
     console.error(error);
     container.textContent = error;
     return;
   }
+  const tags = ([] as Tag[]).concat(...tagGroups.map(group => group.tags));
 
   // eslint-disable-next-line prefer-const
   ({ response: library, error } = await GetLibrary());
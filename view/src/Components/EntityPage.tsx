@@ -0,0 +1,63 @@
+import React, { useEffect, useState } from "react";
+import { Helmet } from "react-helmet";
+import { useParams } from "react-router-dom";
+import "../styles/Entry.less";
+import { useMounted } from "../utils/hooks";
+import { GetAuthor, GetTag } from "../websocket";
+import Entry from "./Entry";
+import Header from "./Header";
+import NotFound from "./NotFound";
+import Spinner from "./Spinner";
+
+interface EntityPageProps {
+  type: "author" | "tag";
+}
+
+const EntityPage = ({ type }: EntityPageProps) => {
+  const { id } = useParams<{ id: string }>();
+  const mountedRef = useMounted();
+
+  const [isLoading, setIsLoading] = useState(true);
+  const [data, setData] = useState<EntityData>();
+
+  useEffect(() => {
+    (async () => {
+      const { response, error } = await (type === "author" ? GetAuthor(id) : GetTag(decodeURIComponent(id)));
+      if (!mountedRef.current) return;
+      if (error) console.error(error);
+
+      setData(response);
+      setIsLoading(false);
+    })();
+  }, [id, type]);
+
+  return (
+    <>
+      <Helmet>
+        <title>{data?.name || id} - Nonbiri</title>
+      </Helmet>
+
+      <Header />
+      <div styleName="library">
+        {isLoading ? (
+          <Spinner styleName="loading" />
+        ) : (
+          (() =>
+            data?.entries?.length ? (
+              <div styleName="libraryContent">
+                {data.entries.map(entry => (
+                  <Entry manga={entry} isBrowse key={entry.id} />
+                ))}
+              </div>
+            ) : (
+              <NotFound title={data?.name || "404 Not Found"}>
+                <p>There are no known manga for this {type}</p>
+              </NotFound>
+            ))()
+        )}
+      </div>
+    </>
+  );
+};
+
+export default EntityPage;
@@ -13,6 +13,7 @@ import Header from "./Header";
 import NotFound from "./NotFound";
 import Picture from "./Picture";
 import Spinner from "./Spinner";
+import NetworkStatus from "./NetworkStatus";
 import UpdateProgress from "./UpdateProgress";
 
 interface HistoryEntry {
@@ -275,6 +276,7 @@ const History = () => {
               </NotFound>
             ) : (
               <>
+                <NetworkStatus />
                 <UpdateProgress />
                 <div styleName="historyContent" ref={ref}>
                   {feed.map((e, i) => (
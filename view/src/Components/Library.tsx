@@ -14,6 +14,7 @@ import Anchor from "./Anchor";
 import Entry from "./Entry";
 import Header from "./Header";
 import NotFound from "./NotFound";
+import NetworkStatus from "./NetworkStatus";
 import UpdateProgress from "./UpdateProgress";
 
 const sortOptions: SortOptions = [
@@ -232,6 +233,7 @@ const Library = () => {
       <div styleName="library">
         {data?.length ? (
           <>
+            <NetworkStatus />
             <UpdateProgress />
             <div styleName="libraryContent" ref={ref}>
               {data.slice(0, Config.library.limit * page).map(v => (
@@ -1,11 +1,22 @@
 import React, { useContext, useMemo, useRef, useState } from "react";
 import { BiChevronDown } from "react-icons/bi";
-import { Demographic, FollowState, FollowStateKeys, Language, Rating, Status } from "../../constants";
+import { routes } from "../../Config";
+import {
+  Demographic,
+  FollowState,
+  FollowStateKeys,
+  Language,
+  Rating,
+  Status,
+  UpdateMode,
+  UpdateModeKeys
+} from "../../constants";
 import styles from "../../styles/Manga.less";
 import { isLink } from "../../utils";
-import { formatThumbnailURL } from "../../utils/encoding";
+import { formatDate, formatThumbnailURL } from "../../utils/encoding";
 import { useModal } from "../../utils/hooks";
-import { FollowManga, UnfollowManga } from "../../websocket";
+import { FollowManga, SetUpdateMode, UnfollowManga } from "../../websocket";
+import Anchor from "../Anchor";
 import Picture from "../Picture";
 import Spinner from "../Spinner";
 import MangaContext from "./MangaContext";
@@ -89,6 +100,43 @@ const FollowStateButton = (props: Props<HTMLDivElement>) => {
   );
 };
 
+const UpdateModeSelect = () => {
+  const { mountedRef, dataRef } = useContext(MangaContext);
+  const [data, setData] = useState<Manga>(dataRef.current);
+
+  const changeUpdateMode = async (ev: React.ChangeEvent<HTMLSelectElement>) => {
+    const { response, error } = await SetUpdateMode(dataRef.current.id, Number(ev.target.value));
+    if (!mountedRef.current) return;
+    if (error) console.error(error);
+    else setData(response);
+  };
+
+  return (
+    <>
+      <li>
+        <strong>Automatic Updates</strong>
+        <div>
+          <select value={data.updateMode || UpdateMode.Auto} onChange={changeUpdateMode}>
+            {UpdateModeKeys.map(k => (
+              <option key={k} value={UpdateMode[k]}>
+                {k}
+              </option>
+            ))}
+          </select>
+        </div>
+      </li>
+      {!!data.nextCheck && (
+        <li>
+          <strong>Next Check</strong>
+          <div>
+            <span>{formatDate(data.nextCheck)}</span>
+          </div>
+        </li>
+      )}
+    </>
+  );
+};
+
 const Sidebar = () => {
   const { dataRef } = useContext(MangaContext);
 
@@ -135,13 +183,15 @@ const Sidebar = () => {
           </li>
         ))}
 
+        {dataRef.current.followed && <UpdateModeSelect />}
+
         {dataRef.current.artists?.length && (
           <li>
             <strong>Artist{dataRef.current.artists.length > 1 && "s"}</strong>
             <div>
               {dataRef.current.artists.map((artist, i) => (
                 <span key={artist.id}>
-                  {artist.name}
+                  <Anchor to={`${routes.author}/${artist.id}`}>{artist.name}</Anchor>
                   {i < dataRef.current.artists.length - 1 && ", "}
                 </span>
               ))}
@@ -155,7 +205,7 @@ const Sidebar = () => {
             <div>
               {dataRef.current.authors.map((author, i) => (
                 <span key={author.id}>
-                  {author.name}
+                  <Anchor to={`${routes.author}/${author.id}`}>{author.name}</Anchor>
                   {i < dataRef.current.authors.length - 1 && ", "}
                 </span>
               ))}
@@ -169,7 +219,7 @@ const Sidebar = () => {
             <div>
               {dataRef.current.tags.map((tag, i) => (
                 <span key={tag}>
-                  {tag}
+                  <Anchor to={`${routes.tag}/${encodeURIComponent(tag)}`}>{tag}</Anchor>
                   {i < dataRef.current.tags.length - 1 && ", "}
                 </span>
               ))}
@@ -0,0 +1,28 @@
+import React, { useEffect, useState } from "react";
+import { Task } from "../constants";
+import "../styles/UpdateProgress.less";
+import websocket, { GetNetworkState } from "../websocket";
+
+const NetworkStatus = () => {
+  const [state, setState] = useState<NetworkState>();
+
+  useEffect(() => {
+    GetNetworkState().then(({ response }) => response && setState(response));
+    return websocket.Handle(Task.GetNetworkState, ({ body }: IncomingMessage<NetworkState>) => setState(body));
+  }, []);
+
+  if (!state || state.online) {
+    return null;
+  }
+
+  return (
+    <div styleName="updater">
+      <strong>
+        {state.offline ? "Offline mode" : "MangaDex is unreachable"}
+        {state.queued > 0 && ` (${state.queued} queued)`}
+      </strong>
+    </div>
+  );
+};
+
+export default NetworkStatus;
@@ -6,6 +6,7 @@ import "../../styles/Reader.less";
 import { loadImage } from "../../utils";
 import { formatGroups, formatPageURL } from "../../utils/encoding";
 import { useMounted } from "../../utils/hooks";
+import { useDataSaver } from "../../utils/quality";
 import Anchor from "../Anchor";
 import NotFound from "../NotFound";
 import Spinner from "../Spinner";
@@ -21,6 +22,7 @@ const Main = () => {
   const queueRef = useRef<number[]>([]);
   const [queue, setQueue] = useState<number[]>([]);
   const parallelSizeRef = useRef(0);
+  const dataSaver = useDataSaver();
 
   const styles: any = useMemo(() => {
     const maxWidth = Number(pref.maxWidth);
@@ -102,7 +104,7 @@ const Main = () => {
         );
 
         (async () => {
-          await loadImage(formatPageURL(chapterRef.current.hash, chapterRef.current.pages[idx]), mountedRef)
+          await loadImage(formatPageURL(chapterRef.current, idx, dataSaver), mountedRef)
             .then(() => (page.isDownloaded = true))
             .catch(() => (page.isFailed = true));
           if (!mountedRef.current) return;
@@ -140,7 +142,7 @@ const Main = () => {
         })();
       }
     }
-  }, [isLoading, isUpdating, chapterRef.current?.pages, queue, pref.maxParallel]);
+  }, [isLoading, isUpdating, chapterRef.current?.pages, queue, pref.maxParallel, dataSaver]);
 
   /**
    * Preserve relative scroll position when resizing window,
@@ -4,6 +4,7 @@ import { PageDirection } from "../../constants";
 import styles from "../../styles/Reader.less";
 import { formatPageURL } from "../../utils/encoding";
 import { useIntersectionObserver, useNavigate } from "../../utils/hooks";
+import { useDataSaver } from "../../utils/quality";
 import ReaderContext from "./ReaderContext";
 
 const Page = ({ state }: { state: PageState }) => {
@@ -27,10 +28,11 @@ const Page = ({ state }: { state: PageState }) => {
   );
   const { ref } = state;
 
+  const dataSaver = useDataSaver();
   const imageRef = useRef<HTMLImageElement>();
   const src = useMemo(
-    () => formatPageURL(chapterRef.current.hash, chapterRef.current.pages[state.num - 1]),
-    [chapterRef.current.pages, chapterRef.current.hash, state]
+    () => formatPageURL(chapterRef.current, state.num - 1, dataSaver),
+    [chapterRef.current.pages, chapterRef.current.hash, state, dataSaver]
   );
 
   const remainderRef = useRef(0);
@@ -12,10 +12,11 @@ import {
 import { IoMdPeople } from "react-icons/io";
 import AppContext from "../../AppContext";
 import { routes } from "../../Config";
-import { PageDirection, PageScale, SidebarPosition, SidebarPositionKeys } from "../../constants";
+import { PageDirection, PageQuality, PageScale, SidebarPosition, SidebarPositionKeys } from "../../constants";
 import "../../styles/Reader.less";
 import { formatChapter, formatGroups } from "../../utils/encoding";
 import { useKeydown, useModal, useMutableHistory, useNavigate } from "../../utils/hooks";
+import { getDeviceQuality, setDeviceQuality } from "../../utils/quality";
 import { UpdateReaderPreference } from "../../websocket";
 import Anchor from "../Anchor";
 import Chapters from "../Chapters";
@@ -39,6 +40,12 @@ const scales: [string, string, PageScale][] = [
   ["Stretch Height", "Stretch to screen height", PageScale.StretchHeight]
 ];
 
+const qualities: [string, string, PageQuality][] = [
+  ["Original", "", PageQuality.Original],
+  ["Data saver", "Compressed pages", PageQuality.DataSaver],
+  ["Auto", "Data saver on cellular connections", PageQuality.Auto]
+];
+
 const noop = () => {};
 
 const Sidebar = () => {
@@ -99,6 +106,11 @@ const Sidebar = () => {
     [pushPreference]
   );
 
+  const changeDeviceQuality = useCallback((ev: React.ChangeEvent<HTMLSelectElement>) => {
+    const { value } = ev.target;
+    setDeviceQuality(value ? (Number(value) as PageQuality) : undefined);
+  }, []);
+
   const changeKeybind = useCallback(
     (ev: React.KeyboardEvent) => {
       clearTimeout(timeoutRef.current);
@@ -377,6 +389,46 @@ const Sidebar = () => {
                     </div>
                   </div>
 
+                  <div styleName="flex gap-1">
+                    <strong>Quality</strong>
+                    <div styleName="flex fill">
+                      <select
+                        styleName="fill"
+                        name="quality"
+                        onChange={e => changePreference(e, true)}
+                        defaultValue={pref.quality}
+                      >
+                        {qualities.map(([text, desc, v]) => (
+                          <option key={PageQuality[v]} value={v}>
+                            {text}
+                            {desc && ` (${desc})`}
+                          </option>
+                        ))}
+                      </select>
+                      <BiChevronDown />
+                    </div>
+                  </div>
+                  <div styleName="flex gap-1">
+                    <strong>This device</strong>
+                    <div styleName="flex fill">
+                      <select
+                        styleName="fill"
+                        name="deviceQuality"
+                        onChange={changeDeviceQuality}
+                        defaultValue={getDeviceQuality() ?? ""}
+                      >
+                        <option value="">Same as above</option>
+                        {qualities.map(([text, desc, v]) => (
+                          <option key={PageQuality[v]} value={v}>
+                            {text}
+                            {desc && ` (${desc})`}
+                          </option>
+                        ))}
+                      </select>
+                      <BiChevronDown />
+                    </div>
+                  </div>
+
                   <h3>Keyboard shortcuts</h3>
                   <div styleName="flex gap-1">
                     <div styleName="flex fill gap-1">
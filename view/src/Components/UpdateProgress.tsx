@@ -1,7 +1,13 @@
 import React, { useEffect, useState } from "react";
 import { Task } from "../constants";
 import "../styles/UpdateProgress.less";
-import websocket from "../websocket";
+import websocket, { CancelUpdateLibrary, PauseUpdateLibrary, ResumeUpdateLibrary } from "../websocket";
+
+const formatETA = (seconds: number) => {
+  if (seconds < 60) return `${seconds}s`;
+  const minutes = Math.round(seconds / 60);
+  return minutes < 60 ? `${minutes}m` : `${Math.floor(minutes / 60)}h ${minutes % 60}m`;
+};
 
 const UpdateProgress = () => {
   const [updateState, setUpdateState] = useState<LibraryUpdateState>();
@@ -29,8 +35,20 @@ const UpdateProgress = () => {
     <div styleName="updater">
       <span style={{ width: `${(updateState.progress / updateState.total) * 100}%` }} />
       <strong>
-        Updating ({updateState.progress}/{updateState.total}): {updateState.current}
+        {updateState.paused ? "Paused" : "Updating"} ({updateState.progress}/{updateState.total}):{" "}
+        {updateState.current}
+        {!!updateState.newChapters && `, ${updateState.newChapters} new`}
+        {!!updateState.failed && `, ${updateState.failed} failed`}
+        {!!updateState.eta && !updateState.paused && `, ${formatETA(updateState.eta)} left`}
       </strong>
+      <div styleName="controls">
+        <button type="button" onClick={() => (updateState.paused ? ResumeUpdateLibrary() : PauseUpdateLibrary())}>
+          {updateState.paused ? "Resume" : "Pause"}
+        </button>
+        <button type="button" onClick={() => CancelUpdateLibrary()}>
+          Cancel
+        </button>
+      </div>
     </div>
   );
 };
@@ -12,6 +12,7 @@ import Header from "./Header";
 import NotFound from "./NotFound";
 import Picture from "./Picture";
 import Spinner from "./Spinner";
+import NetworkStatus from "./NetworkStatus";
 import UpdateProgress from "./UpdateProgress";
 
 interface UpdateEntry {
@@ -228,6 +229,7 @@ const Updates = () => {
           (() =>
             feed?.length ? (
               <>
+                <NetworkStatus />
                 <UpdateProgress />
                 <div styleName="updatesContent" ref={ref}>
                   {feed.map((e, i) => (
@@ -4,7 +4,9 @@ export const routes = {
   updates: "/updates",
   history: "/history",
   manga: "/view",
-  reader: "/read"
+  reader: "/read",
+  author: "/author",
+  tag: "/tag"
 };
 
 export default {
@@ -29,11 +29,119 @@ export enum FollowState {
 export const FollowStateKeys = enumKeys(FollowState);
 export const FollowStateValues = enumValues(FollowState);
 
+export enum UpdateMode {
+  Auto,
+  Never,
+  Daily,
+  Weekly
+}
+
+export const UpdateModeKeys = enumKeys(UpdateMode);
+
+export enum UpdateKind {
+  All,
+  Chapters,
+  Metadata
+}
+
+export enum UpdateStatus {
+  Queued,
+  Updating,
+  Updated,
+  Failed,
+  Cancelled
+}
+
+export enum Failure {
+  None,
+  Other,
+  NotFound,
+  Network,
+  RateLimited,
+  Forbidden
+}
+
+export enum WebhookEvent {
+  Ping,
+  ChapterAdded,
+  MangaFollowed,
+  MangaUnfollowed,
+  StatusChanged,
+  UpdateFinished
+}
+
+export const WebhookEventKeys = enumKeys(WebhookEvent);
+
+export enum DeliveryStatus {
+  Pending,
+  Delivered,
+  Failed
+}
+
 export enum Language {
   English = 1,
   Japan,
   Chinese,
-  Korea
+  Korea,
+  Arabic,
+  Azerbaijani,
+  Belarusian,
+  Bulgarian,
+  Bengali,
+  Catalan,
+  Czech,
+  Chuvash,
+  Danish,
+  German,
+  Greek,
+  Esperanto,
+  Spanish,
+  SpanishLatAm,
+  Estonian,
+  Basque,
+  Persian,
+  Finnish,
+  French,
+  Irish,
+  Hebrew,
+  Hindi,
+  Croatian,
+  Hungarian,
+  Indonesian,
+  Italian,
+  JapanRomanized,
+  Javanese,
+  Georgian,
+  Kazakh,
+  KoreanRomanized,
+  Latin,
+  Lithuanian,
+  Mongolian,
+  Malay,
+  Burmese,
+  Nepali,
+  Dutch,
+  Norwegian,
+  Polish,
+  Portuguese,
+  PortugueseBrazil,
+  Romanian,
+  Russian,
+  Slovak,
+  Albanian,
+  Serbian,
+  Swedish,
+  Tamil,
+  Telugu,
+  Thai,
+  Filipino,
+  Turkish,
+  Ukrainian,
+  Urdu,
+  Uzbek,
+  Vietnamese,
+  ChineseTraditional,
+  ChineseRomanized
 }
 
 export const LanguageKeys = enumKeys(Language);
@@ -105,23 +213,67 @@ export enum Task {
   UnreadChapter,
   GetPages,
 
+  GetAuthor,
+  GetTag,
+  SetUpdateMode,
+  SetMangaLanguages,
+  GetBestChapters,
+  SetMangaGroups,
+
   Library = 30,
   Browse,
   Tags,
   Updates,
   History,
+  Search,
 
   GetPrefs = 40,
   GetBrowsePreference,
   GetLibraryPreference,
   GetReaderPreference,
+  GetNetworkPreference,
+  GetFeedPreference,
+  GetEmailPreference,
+  GetGroupPreference,
 
   UpdateBrowsePreference = 51,
   UpdateLibraryPreference,
   UpdateReaderPreference,
+  UpdateNetworkPreference,
+  UpdateFeedPreference,
+  UpdateEmailPreference,
+  UpdateGroupPreference,
 
   UpdateLibrary = 60,
-  GetUpdateLibraryState
+  GetUpdateLibraryState,
+  CancelUpdateLibrary,
+  PauseUpdateLibrary,
+  ResumeUpdateLibrary,
+  GetUpdateHistory,
+  GetUpdateRun,
+  RetryFailedUpdates,
+
+  GetNetworkState = 70,
+
+  GetWebhooks = 80,
+  SaveWebhook,
+  DeleteWebhook,
+  TestWebhook,
+  GetWebhookLog,
+
+  GetPushKey = 90,
+  GetPushSubscriptions,
+  SubscribePush,
+  UnsubscribePush,
+
+  SendDigest = 100,
+  TestEmail,
+
+  GetEntityFollows = 110,
+  FollowEntity,
+  UnfollowEntity,
+  GetFollowedReleases,
+  CheckFollows
 }
 
 export enum PageDirection {
@@ -142,6 +294,12 @@ export enum PageScale {
   StretchHeight
 }
 
+export enum PageQuality {
+  Original,
+  DataSaver,
+  Auto
+}
+
 export enum SidebarPosition {
   Left = 1,
   Right
@@ -0,0 +1,23 @@
+// Shows the notifications pushed by the server, served as /sw.js
+
+self.addEventListener("push", event => {
+  if (!event.data) return;
+
+  const { title, body, icon, tag, url } = event.data.json();
+  event.waitUntil(self.registration.showNotification(title, { body, icon, tag, data: { url } }));
+});
+
+self.addEventListener("notificationclick", event => {
+  event.notification.close();
+
+  const url = new URL(event.notification.data?.url || "/", self.location.origin).href;
+  event.waitUntil(
+    self.clients.matchAll({ type: "window", includeUncontrolled: true }).then(clients => {
+      const client = clients.find(c => c.url.startsWith(self.location.origin));
+      if (client) {
+        return client.navigate(url).then(c => (c || client).focus());
+      }
+      return self.clients.openWindow(url);
+    })
+  );
+});
@@ -72,9 +72,17 @@ export const formatThumbnailURL = (data: Manga) => {
   return data ? `/0/covers/${data.id}/${data.cover}.256.jpg` : undefined;
 };
 
-export const formatPageURL = (chapterHash: string, fileHash: string) => {
-  if (!chapterHash || !fileHash) return "";
-  return `/0/data/${chapterHash}/${fileHash}`;
+export const formatPageURL = (chapter: Chapter, idx: number, dataSaver?: boolean) => {
+  if (dataSaver && chapter?.dataSaverPages?.[idx]) {
+    return `/0/data-saver/${chapter.hash}/${chapter.dataSaverPages[idx]}`;
+  }
+  if (!chapter?.hash || !chapter.pages?.[idx]) return "";
+  return `/0/data/${chapter.hash}/${chapter.pages[idx]}`;
+};
+
+export const decodeBase64URL = (v: string) => {
+  const padded = v.replace(/-/g, "+").replace(/_/g, "/") + "=".repeat((4 - (v.length % 4)) % 4);
+  return Uint8Array.from(atob(padded), c => c.charCodeAt(0));
 };
 
 const queryArrayFields = [
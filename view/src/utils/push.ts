@@ -0,0 +1,43 @@
+import { GetPushKey, SubscribePush, UnsubscribePush } from "../websocket";
+import { decodeBase64URL } from "./encoding";
+
+export const isPushSupported = () => "serviceWorker" in navigator && "PushManager" in window;
+
+const register = () => navigator.serviceWorker.register("/sw.js");
+
+/**
+ * Subscribes the browser to the notifications of new chapters,
+ * every followed manga is notified when both filters are empty.
+ */
+export const subscribePush = async (filters?: PushFilters) => {
+  const registration = await register();
+
+  let subscription = await registration.pushManager.getSubscription();
+  if (!subscription) {
+    const { response: key, error } = await GetPushKey();
+    if (error) throw error;
+
+    subscription = await registration.pushManager.subscribe({
+      userVisibleOnly: true,
+      applicationServerKey: decodeBase64URL(key)
+    });
+  }
+
+  const { keys } = subscription.toJSON();
+  return SubscribePush({
+    endpoint: subscription.endpoint,
+    p256dh: keys.p256dh,
+    auth: keys.auth,
+    mangaIds: filters?.mangaIds || [],
+    followStates: filters?.followStates || []
+  });
+};
+
+export const unsubscribePush = async () => {
+  const registration = await register();
+  const subscription = await registration.pushManager.getSubscription();
+  if (!subscription) return;
+
+  await UnsubscribePush(subscription.endpoint);
+  await subscription.unsubscribe();
+};
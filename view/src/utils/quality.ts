@@ -0,0 +1,67 @@
+import { useContext, useEffect, useState } from "react";
+import AppContext from "../AppContext";
+import { PageQuality } from "../constants";
+
+// The quality chosen for this device only, it takes precedence over the reader preference
+const storageKey = "pageQuality";
+const changeEvent = "pagequalitychange";
+
+export const getDeviceQuality = (): PageQuality | undefined => {
+  const v = localStorage.getItem(storageKey);
+  return v === null ? undefined : (Number(v) as PageQuality);
+};
+
+export const setDeviceQuality = (quality?: PageQuality) => {
+  if (quality === undefined) {
+    localStorage.removeItem(storageKey);
+  } else {
+    localStorage.setItem(storageKey, String(quality));
+  }
+  window.dispatchEvent(new Event(changeEvent));
+};
+
+// https://developer.mozilla.org/en-US/docs/Web/API/NetworkInformation
+const getConnection = (): any => (navigator as any).connection;
+
+const isMetered = () => {
+  const connection = getConnection();
+  if (!connection) return false;
+  return (
+    connection.saveData ||
+    connection.type === "cellular" ||
+    ["slow-2g", "2g", "3g"].includes(connection.effectiveType)
+  );
+};
+
+const resolve = (quality: PageQuality) => {
+  switch (quality) {
+    case PageQuality.DataSaver:
+      return true;
+    case PageQuality.Auto:
+      return isMetered();
+    default:
+      return false;
+  }
+};
+
+// useDataSaver returns whether the compressed pages should be read on this device
+export const useDataSaver = () => {
+  const { reader: pref } = useContext(AppContext).context.prefs;
+  const quality = () => getDeviceQuality() ?? pref.quality;
+  const [dataSaver, setDataSaver] = useState(() => resolve(quality()));
+
+  useEffect(() => {
+    const update = () => setDataSaver(resolve(quality()));
+    update();
+
+    const connection = getConnection();
+    window.addEventListener(changeEvent, update);
+    connection?.addEventListener("change", update);
+    return () => {
+      window.removeEventListener(changeEvent, update);
+      connection?.removeEventListener("change", update);
+    };
+  }, [pref.quality]);
+
+  return dataSaver;
+};
@@ -1,4 +1,4 @@
-import { FollowState, Task } from "./constants";
+import { FollowState, Language, Task, UpdateMode } from "./constants";
 
 interface Result<T = any> {
   response?: T;
@@ -112,6 +112,17 @@ export const FollowManga = (mangaId: string, followState: FollowState) =>
 
 export const UnfollowManga = (mangaId: string) => SendMessage<Manga>(Task.UnfollowManga, mangaId);
 
+export const SetUpdateMode = (mangaId: string, updateMode: UpdateMode) =>
+  SendMessage<Manga>(Task.SetUpdateMode, { mangaId, updateMode });
+
+// An empty list falls back to the preferred languages
+export const SetMangaLanguages = (mangaId: string, languages: Language[]) =>
+  SendMessage<Manga>(Task.SetMangaLanguages, { mangaId, languages });
+
+// The groups are chosen before the globally preferred ones
+export const SetMangaGroups = (mangaId: string, groups: Entity[]) =>
+  SendMessage<Manga>(Task.SetMangaGroups, { mangaId, groups });
+
 //
 
 export const GetChapter = (chapterId: string) => SendMessage<Chapter>(Task.GetChapter, chapterId);
@@ -120,6 +131,9 @@ export const UpdateChapter = (chapterId: string) => SendMessage<Chapter>(Task.Up
 
 export const GetChapters = (mangaId: string) => SendMessage<Chapter[]>(Task.GetChapters, mangaId);
 
+// A single release per chapter number, in the best available language then of the most preferred group
+export const GetBestChapters = (mangaId: string) => SendMessage<Chapter[]>(Task.GetBestChapters, mangaId);
+
 export const UpdateChapters = (mangaId: string) => SendMessage<Chapter[]>(Task.UpdateChapters, mangaId);
 
 //
@@ -136,11 +150,17 @@ export const GetPages = (chapterId: string) => SendMessage<Chapter>(Task.GetPage
 
 //
 
+export const GetAuthor = (authorId: string) => SendMessage<EntityData>(Task.GetAuthor, authorId);
+
+export const GetTag = (tag: string) => SendMessage<EntityData>(Task.GetTag, tag);
+
+//
+
 export const GetLibrary = () => SendMessage<Manga[]>(Task.Library);
 
 export const GetBrowse = (q: BrowseQuery) => SendMessage<BrowseData>(Task.Browse, q);
 
-export const GetTags = () => SendMessage<Tag[]>(Task.Tags);
+export const GetTags = () => SendMessage<TagGroup[]>(Task.Tags);
 
 export const GetUpdates = () => SendMessage<Chapter[]>(Task.Updates);
 
@@ -156,6 +176,14 @@ export const GetLibraryPreference = () => SendMessage<LibraryPreference>(Task.Ge
 
 export const GetReaderPreference = () => SendMessage<ReaderPreference>(Task.GetReaderPreference);
 
+export const GetNetworkPreference = () => SendMessage<NetworkPreference>(Task.GetNetworkPreference);
+
+export const GetFeedPreference = () => SendMessage<FeedPreference>(Task.GetFeedPreference);
+
+export const GetEmailPreference = () => SendMessage<EmailPreference>(Task.GetEmailPreference);
+
+export const GetGroupPreference = () => SendMessage<GroupPreference>(Task.GetGroupPreference);
+
 //
 
 export const UpdateBrowsePreference = (data: BrowsePreference) =>
@@ -167,12 +195,88 @@ export const UpdateLibraryPreference = (data: LibraryPreference) =>
 export const UpdateReaderPreference = (data: ReaderPreference) =>
   SendMessage<ReaderPreference>(Task.UpdateReaderPreference, data);
 
+export const UpdateNetworkPreference = (data: NetworkPreference) =>
+  SendMessage<NetworkPreference>(Task.UpdateNetworkPreference, data);
+
+// An empty token generates a new one
+export const UpdateFeedPreference = (data: FeedPreference) =>
+  SendMessage<FeedPreference>(Task.UpdateFeedPreference, data);
+
+export const UpdateEmailPreference = (data: EmailPreference) =>
+  SendMessage<EmailPreference>(Task.UpdateEmailPreference, data);
+
+export const UpdateGroupPreference = (data: GroupPreference) =>
+  SendMessage<GroupPreference>(Task.UpdateGroupPreference, data);
+
 //
 
 export const UpdateLibrary = () => SendMessage<LibraryUpdateState>(Task.UpdateLibrary);
 
 export const GetUpdateLibraryState = () => SendMessage<LibraryUpdateState>(Task.GetUpdateLibraryState);
 
+export const CancelUpdateLibrary = () => SendMessage<LibraryUpdateState>(Task.CancelUpdateLibrary);
+
+export const PauseUpdateLibrary = () => SendMessage<LibraryUpdateState>(Task.PauseUpdateLibrary);
+
+export const ResumeUpdateLibrary = () => SendMessage<LibraryUpdateState>(Task.ResumeUpdateLibrary);
+
+export const GetUpdateHistory = () => SendMessage<UpdateHistory>(Task.GetUpdateHistory);
+
+export const GetUpdateRun = (runId: number) => SendMessage<UpdateRun>(Task.GetUpdateRun, { runId });
+
+// Retries the manga which failed in the run, or the ones which keep failing when runId is omitted
+export const RetryFailedUpdates = (runId?: number) =>
+  SendMessage<LibraryUpdateState>(Task.RetryFailedUpdates, runId ? { runId } : undefined);
+
+//
+
+export const GetNetworkState = () => SendMessage<NetworkState>(Task.GetNetworkState);
+
+//
+
+export const GetWebhooks = () => SendMessage<Webhook[]>(Task.GetWebhooks);
+
+// Creates the webhook, or updates it when it has an id
+export const SaveWebhook = (data: Webhook) => SendMessage<Webhook>(Task.SaveWebhook, data);
+
+export const DeleteWebhook = (webhookId: number) => SendMessage(Task.DeleteWebhook, { webhookId });
+
+export const TestWebhook = (webhookId: number) => SendMessage<WebhookDelivery>(Task.TestWebhook, { webhookId });
+
+export const GetWebhookLog = (webhookId: number) => SendMessage<WebhookDelivery[]>(Task.GetWebhookLog, { webhookId });
+
+//
+
+export const GetPushKey = () => SendMessage<string>(Task.GetPushKey);
+
+export const GetPushSubscriptions = () => SendMessage<PushSubscriptionData[]>(Task.GetPushSubscriptions);
+
+export const SubscribePush = (data: PushSubscriptionData) =>
+  SendMessage<PushSubscriptionData>(Task.SubscribePush, data);
+
+export const UnsubscribePush = (endpoint: string) => SendMessage(Task.UnsubscribePush, { endpoint });
+
+//
+
+// Emails the chapters discovered since the last digest, resolves to the number of chapters
+export const SendDigest = () => SendMessage<number>(Task.SendDigest);
+
+export const TestEmail = () => SendMessage(Task.TestEmail);
+
+//
+
+export const GetEntityFollows = () => SendMessage<EntityFollow[]>(Task.GetEntityFollows);
+
+// The releases of the followed groups and authors are checked after every library update
+export const FollowEntity = (data: EntityFollow) => SendMessage<EntityFollow>(Task.FollowEntity, data);
+
+export const UnfollowEntity = (id: string) => SendMessage(Task.UnfollowEntity, id);
+
+export const GetFollowedReleases = (limit?: number) =>
+  SendMessage<FollowedRelease[]>(Task.GetFollowedReleases, limit ? { limit } : undefined);
+
+export const CheckFollows = () => SendMessage<FollowedRelease[]>(Task.CheckFollows);
+
 //
 
 export default {
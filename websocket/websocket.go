@@ -1,11 +1,13 @@
 package websocket
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
 
 	"nonbiri/utils"
+	"nonbiri/utils/metrics"
 
 	. "nonbiri/constants"
 
@@ -17,12 +19,28 @@ import (
 type Connection struct {
 	*websocket.Conn
 	Send chan *OutgoingMessage
+
+	// Cancelled once the connection is closed, to stop the tasks it requested
+	ctx    context.Context
+	cancel context.CancelFunc
+	// Closed once the messages are no longer written, Send must not be used then
+	done chan struct{}
 }
 
 type IncomingMessage struct {
 	Identifier int  `json:"identifier"`
 	Task       Task `json:"task"`
 	Body       any  `json:"body,omitempty"`
+
+	ctx context.Context
+}
+
+// Context is done once the connection which sent the message is closed
+func (m *IncomingMessage) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
 }
 
 type OutgoingMessage struct {
@@ -45,6 +63,28 @@ var (
 	Broadcast   = make(chan *OutgoingMessage)
 	Register    = make(chan *Connection)
 	Unregister  = make(chan *Connection)
+
+	closing  = make(chan closeRequest)
+	stopping = make(chan struct{})
+	stop     sync.Once
+)
+
+type closeRequest struct {
+	ctx  context.Context
+	done chan struct{}
+}
+
+// The handlers of the incoming messages, waited for by Close
+var handlers = struct {
+	stopped bool
+	wg      sync.WaitGroup
+	sync.Mutex
+}{}
+
+var (
+	connectionsGauge = metrics.NewGauge("nonbiri_websocket_connections", "Open websocket connections.")
+	tasksCounter     = metrics.NewCounter("nonbiri_tasks_total", "Tasks handled by task and result.", "task", "result")
+	taskDuration     = metrics.NewHistogram("nonbiri_task_duration_seconds", "Time spent handling tasks.", nil, "task")
 )
 
 var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
@@ -60,17 +100,18 @@ func init() {
 			select {
 			case conn := <-Register:
 				Connections[conn] = true
+				connectionsGauge.Set(float64(len(Connections)))
 			case conn := <-Unregister:
-				if _, exists := Connections[conn]; exists {
-					delete(Connections, conn)
-					close(conn.Send)
-				}
+				delete(Connections, conn)
+				connectionsGauge.Set(float64(len(Connections)))
 			case message := <-Broadcast:
 				for conn, ok := range Connections {
 					if ok {
-						conn.Send <- message
+						conn.send(message)
 					}
 				}
+			case req := <-closing:
+				closeConnections(req)
 			}
 		}
 	}()
@@ -83,13 +124,91 @@ func Serve(c *gin.Context) {
 		return
 	}
 
-	connection := &Connection{Conn: conn, Send: make(chan *OutgoingMessage)}
+	connection := &Connection{Conn: conn, Send: make(chan *OutgoingMessage), done: make(chan struct{})}
+	connection.ctx, connection.cancel = context.WithCancel(context.Background())
 	Register <- connection
 
 	go connection.handleIncomingMessage()
 	go connection.handleOutgoingMessage()
 }
 
+// Close sends a close frame to every connection and closes them,
+// then waits for the handlers of their messages, whose tasks are cancelled, to return.
+// It gives up once ctx is done.
+func Close(ctx context.Context) error {
+	stop.Do(func() { close(stopping) })
+
+	handlers.Lock()
+	handlers.stopped = true
+	handlers.Unlock()
+
+	req := closeRequest{ctx: ctx, done: make(chan struct{})}
+	select {
+	case closing <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		<-req.done
+		handlers.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closeConnections writes the close frames concurrently so a slow connection does not hold back the others,
+// req.done is closed once every connection has been closed.
+func closeConnections(req closeRequest) {
+	deadline := time.Now().Add(writeWait)
+	if d, ok := req.ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down")
+
+	wg := sync.WaitGroup{}
+	for conn := range Connections {
+		wg.Add(1)
+		go func(conn *Connection) {
+			defer wg.Done()
+			_ = conn.WriteControl(websocket.CloseMessage, msg, deadline)
+			_ = conn.Close()
+		}(conn)
+	}
+	go func() {
+		wg.Wait()
+		close(req.done)
+	}()
+}
+
+// send queues the message unless the connection is closed or the server is shutting down
+func (self *Connection) send(message *OutgoingMessage) {
+	select {
+	case self.Send <- message:
+	case <-self.done:
+	case <-stopping:
+	}
+}
+
+// beginHandler registers the handler of a message, returns false once Close has been called
+func beginHandler() bool {
+	handlers.Lock()
+	defer handlers.Unlock()
+
+	if handlers.stopped {
+		return false
+	}
+	handlers.wg.Add(1)
+	return true
+}
+
 func Handle(task Task, handler TaskHandler) {
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -98,6 +217,7 @@ func Handle(task Task, handler TaskHandler) {
 
 func (self *Connection) handleIncomingMessage() {
 	defer func() {
+		self.cancel()
 		Unregister <- self
 		_ = self.Close()
 	}()
@@ -116,8 +236,13 @@ func (self *Connection) handleIncomingMessage() {
 			break
 		}
 
+		if !beginHandler() {
+			break
+		}
 		go func(buf []byte) {
-			message := &IncomingMessage{}
+			defer handlers.wg.Done()
+
+			message := &IncomingMessage{ctx: self.ctx}
 			if err := utils.Unmarshal(buf, message); err != nil {
 				log.Println(err)
 				return
@@ -128,8 +253,17 @@ func (self *Connection) handleIncomingMessage() {
 			mutex.Unlock()
 
 			if exists {
+				start := time.Now()
 				res, err := handler(message)
 
+				task := message.Task.String()
+				taskDuration.Since(start, task)
+				if err != nil {
+					tasksCounter.Inc(task, "error")
+				} else {
+					tasksCounter.Inc(task, "ok")
+				}
+
 				if res != nil || err != nil {
 					reply := &OutgoingMessage{Identifier: message.Identifier, Task: message.Task, Body: res}
 					if err != nil {
@@ -140,13 +274,12 @@ func (self *Connection) handleIncomingMessage() {
 					switch message.Task {
 					case Tasks.UpdateManga, Tasks.FollowManga, Tasks.UnfollowManga,
 						Tasks.UpdateChapter, Tasks.UpdateChapters, Tasks.ReadPage,
-						Tasks.ReadChapter, Tasks.UnreadChapter, Tasks.UpdateReaderPreference:
+						Tasks.ReadChapter, Tasks.UnreadChapter, Tasks.UpdateReaderPreference,
+						Tasks.UpdateNetworkPreference:
 						Broadcast <- reply
 						break
 					default:
-						if _, exists := Connections[self]; exists {
-							self.Send <- reply
-						}
+						self.send(reply)
 						break
 					}
 				}
@@ -161,21 +294,23 @@ func (self *Connection) handleOutgoingMessage() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
+		close(self.done)
 		_ = self.Close()
 	}()
 
 	for {
 		select {
-		case message, ok := <-self.Send:
-			if _ = self.SetWriteDeadline(time.Now().Add(writeWait)); ok {
-				if buf, err := utils.Marshal(message); err == nil {
-					_ = self.WriteMessage(websocket.TextMessage, buf)
-				} else {
-					_ = self.WriteMessage(websocket.TextMessage, []byte(err.Error()))
-				}
+		case message := <-self.Send:
+			_ = self.SetWriteDeadline(time.Now().Add(writeWait))
+			if buf, err := utils.Marshal(message); err == nil {
+				_ = self.WriteMessage(websocket.TextMessage, buf)
 			} else {
-				_ = self.WriteMessage(websocket.CloseMessage, nil)
+				_ = self.WriteMessage(websocket.TextMessage, []byte(err.Error()))
 			}
+		case <-self.ctx.Done():
+			_ = self.SetWriteDeadline(time.Now().Add(writeWait))
+			_ = self.WriteMessage(websocket.CloseMessage, nil)
+			return
 		case <-ticker.C:
 			_ = self.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := self.WriteMessage(websocket.PingMessage, nil); err != nil {